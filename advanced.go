@@ -1,454 +1,186 @@
 package goors
 
 import (
-	"github.com/jasn/gorasp"
-	"math/bits"
-	"sort"
+	"cmp"
+	"runtime"
 )
 
+// RangeSearchAdvancedOf answers orthogonal range queries in O(log n + k) time, see the README for how it works.
+// The coordinates can be of any type T that can be compared. They are reduced to rank space and the actual work is done
+// by a RankSpaceIndex, so there is no loss of precision from e.g. converting int64 or time.Time to float64.
+//
+// Build (or BuildParallel) must return before queries are issued. After that the structure is never modified,
+// so any number of goroutines may call Query, QueryBatch and QueryBatchStream concurrently without locking.
+// Calling Build while queries are running is not safe. A query on a structure that has not been built returns no results.
+type RangeSearchAdvancedOf[T any] struct {
+	points    []PointOf[T]
+	compare   func(a, b T) int
+	rankSpace *RankSpaceOf[T]
+	index     *RankSpaceIndex
+
+	// passed on to the index when building.
+	rankSelectFactory RankerFactory
+	layout            TreeLayout
+	bucketSize        int
+	colors            []int
+	attributes        []uint64
+
+	// Query scans all the points when it would report more than this fraction of them, see SetScanFraction.
+	scanFraction float64
+}
+
+// The fraction of the points a query must report before Query scans all the points instead of using the tree.
+// Since the size of the result is known before reporting, the tree copies whole runs of ball-inheritance into a
+// preallocated result, which BenchmarkScanFraction shows to be faster than scanning even when reporting 80% of
+// uniformly distributed points, so by default the scan is never used.
+const DefaultScanFraction = 1.0
+
+// RangeSearchAdvanced is the RangeSearchAdvancedOf used for float64 coordinates.
 type RangeSearchAdvanced struct {
-	points               []Point
-	pointsRankSpace      []pointRankPerm
-	xTree                []int
-	xTreeHeight          int // number of nodes on root to leaf path including root and leaf.
-	bitArrays            [][]int
-	rankSelectStructures []gorasp.RankSelect
-	ballInheritance      [][]int
-	xCoords              []float64
-	yCoords              []float64
+	RangeSearchAdvancedOf[float64]
 }
 
-func getNextPowerOfTwo(n int) int {
-	if n <= 2 {
-		return n
-	}
-	n = n - 1
-	for i := uint(1); n > 0; i++ {
-		n = n >> 1
-		if n == 0 {
-			return 1 << i
-		}
-	}
-	return 1 << 30
-}
-
-// when processing a query we receive floats, but the rest of our structure uses rank space
-// This function finds the corresponding rank-space coordinates for the query range.
-func (self *RangeSearchAdvanced) getRankSpacePoints(bottomLeft, topRight Point) (pointRankPerm, pointRankPerm) {
-	bottomLeftRes := pointRankPerm{0, 0, -1}
-	topRightRes := pointRankPerm{0, 0, -1}
-
-	bottomLeftRes.x = sort.SearchFloat64s(self.xCoords, bottomLeft.x)
-	bottomLeftRes.y = sort.SearchFloat64s(self.yCoords, bottomLeft.y)
-
-	topRightRes.x = sort.Search(len(self.xCoords), func(i int) bool {
-		return self.xCoords[i] > topRight.x
-	})
-	topRightRes.y = sort.Search(len(self.yCoords), func(i int) bool {
-		return self.yCoords[i] > topRight.y
-	})
-	return bottomLeftRes, topRightRes
-}
-
-// This is a neat trick to find the LCA of two nodes on the *same* level (that detail is important!)
-// It of course only works when we zero-index and use a heap-layout.
-func lowestCommonAncestor(left, right int) int {
-	xor := uint64((left + 1) ^ (right + 1))
-	zeros := bits.LeadingZeros64(xor)
-	shift := uint(64 - zeros)
-	return ((left + 1) >> shift) - 1
-}
-
-// currentIndex denotes a y-rank at the current node.
-// when descending we want to maintain an interval [l,r] such that all y-coordinates to be reported fall in that range.
-// this function is used when descending to the left.
-func descendLeft(currentIndex int, rankSelectStruct gorasp.RankSelect) int {
-	onesLeft := rankSelectStruct.RankOfIndex(currentIndex)
-	zeros := currentIndex - int(onesLeft)
-	return zeros
-}
-
-// similar to descendLeft
-func descendRight(currentIndex int, rankSelectStruct gorasp.RankSelect) int {
-	onesLeft := rankSelectStruct.RankOfIndex(currentIndex)
-	return int(onesLeft)
-}
-
-// the last half of the array self.xTree are leaves.
-// this function tests if a node represented by n is in fact a leaf.
-func isLeaf(n int, self *RangeSearchAdvanced) bool {
-	return n >= len(self.xTree)/2
-}
-
-// Reports everything hanging at or below node, with y-ranks [yLeft, yRight[ (half open interval).
-func (self *RangeSearchAdvanced) reportAll(node, yLeft, yRight int) []int {
-	if isLeaf(node, self) {
-		indexInSortedPoints := node - len(self.xTree)/2
-		if yLeft < yRight {
-			return []int{self.pointsRankSpace[indexInSortedPoints].i}
-		}
+// The query algorithm for the structure.
+// Assumes bottomLeft, is in fact less than topRight on both the x and y coordinates.
+// return a slice of indices, each is an index into self.points, which is in the order it was given to the constructor.
+// The size of the result is computed first, in O(log n) time, and if it exceeds the scan fraction of n (see SetScanFraction)
+// the points are scanned like RangeSearchSimple does, in which case the result is ordered by index.
+func (self *RangeSearchAdvancedOf[T]) Query(bottomLeft, topRight PointOf[T]) []int {
+	if self.index == nil {
+		// not built yet.
 		return []int{}
 	}
-
-	result := make([]int, yRight-yLeft)
-	for i := yLeft; i < yRight; i++ {
-		result[i-yLeft] = self.ballInheritance[node][i]
-	}
-	return result
-}
-
-// appends the smaller list to the bigger list.
-func merge(left, right []int) []int {
-	if len(right) > len(left) {
-		for _, v := range left {
-			right = append(right, v)
-		}
-		return right
-	} else {
-		for _, v := range right {
-			left = append(left, v)
-		}
-		return left
+	x0, x1, y0, y1 := self.rankSpace.ReduceRect(MakeRectOf(bottomLeft, topRight))
+	d := self.index.decompose(x0, x1, y0, y1, self.index.descendToLca)
+	count := self.index.count(d)
+	if float64(count) > self.scanFraction*float64(len(self.points)) {
+		return self.scan(x0, x1, y0, y1, count)
 	}
+	return self.index.report(d, count)
 }
 
-// After finding the lca, this function is called with node=lca's right child.
-// This function then keeps descending toward the node with key xRankMax, while
-// reporting all subtrees that are strictly to the left.
-func (self *RangeSearchAdvanced) reportLeftHanging(node, yLeft, yRight, xRankMax int) []int {
-	if isLeaf(node, self) {
-		if self.xTree[node] == -1 {
-			return []int{}
-		}
-		index := node - len(self.xTree)/2
-		point := self.pointsRankSpace[index]
-		if point.x < xRankMax && point.y >= yLeft && yLeft < yRight {
-			return []int{point.i}
-		}
-		return []int{}
-	}
-	rightChild := 2*node + 2
-	leftChild := 2*node + 1
-
-	keyOfMe := self.xTree[node]
-	if keyOfMe == -1 {
-		return []int{}
-	}
-	if xRankMax > keyOfMe {
-		// report left childs everything.
-		yLeftTmp := descendLeft(yLeft, self.rankSelectStructures[node])
-		yRightTmp := descendLeft(yRight, self.rankSelectStructures[node])
-		result_left := self.reportAll(leftChild, yLeftTmp, yRightTmp)
-
-		// then descend right.
-		yLeftNew := descendRight(yLeft, self.rankSelectStructures[node])
-		yRightNew := descendRight(yRight, self.rankSelectStructures[node])
-		result_right := self.reportLeftHanging(rightChild, yLeftNew, yRightNew, xRankMax)
-		return merge(result_left, result_right)
-	} else {
-		// descendRight and do the same again.
-		yLeftNew := descendLeft(yLeft, self.rankSelectStructures[node])
-		yRightNew := descendLeft(yRight, self.rankSelectStructures[node])
-		return self.reportLeftHanging(leftChild, yLeftNew, yRightNew, xRankMax)
+// Same as Query, but reports at most limit points, and whether there were more.
+// Since the size of the result is known before reporting, only limit points are ever enumerated.
+func (self *RangeSearchAdvancedOf[T]) QueryLimit(bottomLeft, topRight PointOf[T], limit int) ([]int, bool) {
+	if self.index == nil {
+		// not built yet.
+		return []int{}, false
 	}
+	x0, x1, y0, y1 := self.rankSpace.ReduceRect(MakeRectOf(bottomLeft, topRight))
+	return self.index.QueryRanksLimit(x0, x1, y0, y1, limit)
 }
 
-// symmetric to reportLeftHanging
-func (self *RangeSearchAdvanced) reportRightHanging(node, yLeft, yRight, xRankMin int) []int {
-	if isLeaf(node, self) {
-		index := node - len(self.xTree)/2
-		point := self.pointsRankSpace[index]
-		if point.x >= xRankMin && point.y >= yLeft && yLeft < yRight {
-			return []int{point.i}
+// Reports the count points with x-ranks in [x0, x1[ and y-ranks in [y0, y1[ by testing every point.
+// The points are tested in rank space, which avoids calling compare.
+func (self *RangeSearchAdvancedOf[T]) scan(x0, x1, y0, y1, count int) []int {
+	result := make([]int, 0, count)
+	for index, p := range self.rankSpace.RankPoints() {
+		if p.X >= x0 && p.X < x1 && p.Y >= y0 && p.Y < y1 {
+			result = append(result, index)
 		}
-		return []int{}
-	}
-
-	rightChild := 2*node + 2
-	leftChild := 2*node + 1
-
-	keyOfMe := self.xTree[node]
-	if keyOfMe == -1 {
-		return []int{}
-	}
-
-	if xRankMin <= keyOfMe {
-		// report right childs everything.
-		yLeftTmp := descendRight(yLeft, self.rankSelectStructures[node])
-		yRightTmp := descendRight(yRight, self.rankSelectStructures[node])
-		result_left := self.reportAll(rightChild, yLeftTmp, yRightTmp)
-
-		// then descend left.
-		yLeftNew := descendLeft(yLeft, self.rankSelectStructures[node])
-		yRightNew := descendLeft(yRight, self.rankSelectStructures[node])
-		result_right := self.reportRightHanging(leftChild, yLeftNew, yRightNew, xRankMin)
-		return merge(result_left, result_right)
-	} else {
-		// descendRight and do the same again.
-		yLeftNew := descendRight(yLeft, self.rankSelectStructures[node])
-		yRightNew := descendRight(yRight, self.rankSelectStructures[node])
-		return self.reportRightHanging(rightChild, yLeftNew, yRightNew, xRankMin)
 	}
+	return result
 }
 
-// This function computes the y-rank-interval at a node lca, given that at the root the interval is [yLeft, yRight[ (half open).
-func (self *RangeSearchAdvanced) descendToLca(lca, yLeft, yRight int) (int, int) {
-	searchKey := self.xTree[lca]
-	yLeftNew := yLeft
-	yRightNew := yRight
-	node := 0
-	for node != lca && node < len(self.xTree) {
-		key := self.xTree[node]
-		if searchKey <= key {
-			yLeftNew = descendLeft(yLeftNew, self.rankSelectStructures[node])
-			yRightNew = descendLeft(yRightNew, self.rankSelectStructures[node])
-			node = 2*node + 1
-		} else {
-			yLeftNew = descendRight(yLeftNew, self.rankSelectStructures[node])
-			yRightNew = descendRight(yRightNew, self.rankSelectStructures[node])
-			node = 2*node + 2
-		}
+// Same as Query, but returns an *InvalidRectError instead of an empty result
+// if bottomLeft is not below-left of topRight.
+func (self *RangeSearchAdvanced) QueryE(bottomLeft, topRight Point) ([]int, error) {
+	if err := validateRect(bottomLeft, topRight); err != nil {
+		return nil, err
 	}
-	return yLeftNew, yRightNew
+	return self.Query(bottomLeft, topRight), nil
 }
 
-// convenience function, called with node=lca when processing a query.
-// Initiates the search towards the lower x-coordinate in the query range.
-func (self *RangeSearchAdvanced) branchLeftReport(node, yLeft, yRight, xMinRank int) []int {
-	leftChild := 2*node + 1
-	yLeftNew := descendLeft(yLeft, self.rankSelectStructures[node])
-	yRightNew := descendLeft(yRight, self.rankSelectStructures[node])
-	return self.reportRightHanging(leftChild, yLeftNew, yRightNew, xMinRank)
+// Selects how the nodes of the tree are laid out in memory, see TreeLayout. Must be called before Build.
+func (self *RangeSearchAdvancedOf[T]) SetLayout(layout TreeLayout) {
+	self.layout = layout
 }
 
-// symmetric to branchLeftReport
-func (self *RangeSearchAdvanced) branchRightReport(node, yLeft, yRight, xMaxRank int) []int {
-	rightChild := 2*node + 2
-	yLeftNew := descendRight(yLeft, self.rankSelectStructures[node])
-	yRightNew := descendRight(yRight, self.rankSelectStructures[node])
-	return self.reportLeftHanging(rightChild, yLeftNew, yRightNew, xMaxRank)
+// Lets the leaves of the tree hold up to bucketSize points each, see RankSpaceIndex.SetBucketSize. Must be called before Build.
+func (self *RangeSearchAdvancedOf[T]) SetBucketSize(bucketSize int) {
+	self.bucketSize = bucketSize
 }
 
-// determines if point is contained in the rectangle defined by bottomLeft, topRight.
-func isContained(bottomLeft, topRight, point Point) bool {
-	return point.x >= bottomLeft.x && point.x <= topRight.x && point.y >= bottomLeft.y && point.y <= topRight.y
+// Sets the fraction of the points (DefaultScanFraction unless set) a query must report before Query
+// switches from the tree to scanning all the points. A fraction of 1 or more disables scanning.
+func (self *RangeSearchAdvancedOf[T]) SetScanFraction(fraction float64) {
+	self.scanFraction = fraction
 }
 
-// Checks if both x-coordinates ended up in the same leaf.
-// can happen either at the very end meaning the rightLeafIndex is one past the end of the array
-func bothXCoordinatesInSameLeaf(leafIndexLeft, leafIndexRight, onePastLastLeafIndex int) bool {
-	caseOne := leafIndexLeft == leafIndexRight
-	caseTwo := leafIndexRight == onePastLastLeafIndex && leafIndexLeft == onePastLastLeafIndex-1
-	return caseOne || caseTwo
+// Sets the factory used to create the rank structures of the bit arrays, overriding the package-level RankSelectFactory.
+// Must be called before Build.
+func (self *RangeSearchAdvancedOf[T]) SetRankSelectFactory(factory RankerFactory) {
+	self.rankSelectFactory = factory
 }
 
-// The query algorithm for the structure.
-// Assumes bottomLeft, is in fact less than topRight on both the x and y coordinates.
-// return a slice of indices, each is an index into self.points, which is in the order it was given to the constructor.
-func (self *RangeSearchAdvanced) Query(bottomLeft, topRight Point) []int {
-	bottomLeftRank, topRightRank := self.getRankSpacePoints(bottomLeft, topRight)
-	leafIndexLeft := len(self.xTree)/2 + bottomLeftRank.x
-	leafIndexRight := len(self.xTree)/2 + topRightRank.x
-
-	onePastLastLeafIndex := len(self.xTree)/2 + len(self.points)
-	// special case
-	if bothXCoordinatesInSameLeaf(leafIndexLeft, leafIndexRight, onePastLastLeafIndex) {
-		if leafIndexLeft >= onePastLastLeafIndex {
-			result := make([]int, 0)
-			return result
-		}
-		idx := leafIndexLeft - len(self.xTree)/2
-		point := self.points[self.pointsRankSpace[idx].i]
-		if isContained(bottomLeft, topRight, point) {
-			result := make([]int, 1)
-			result[0] = self.pointsRankSpace[idx].i
-			return result
-		} else {
-			result := make([]int, 0)
-			return result
-		}
-	}
-
-	// general case
-	var lca int = 0
-	if leafIndexRight < len(self.xTree) {
-		lca = lowestCommonAncestor(leafIndexLeft, leafIndexRight)
-	} else {
-		lca = lowestCommonAncestor(leafIndexLeft, leafIndexRight-1)
-	}
-	yLeft, yRight := self.descendToLca(lca, bottomLeftRank.y, topRightRank.y)
-
-	result := self.branchLeftReport(lca, yLeft, yRight, bottomLeftRank.x)
-	result = append(
-		result,
-		self.branchRightReport(lca, yLeft, yRight, topRightRank.x)...,
-	)
-	return result
-}
-
-// helper function used to build ball-inheritace and bit arrays.
-// This function is called with elements in self.pointsRankspace by increasing y-rank.
-func (self *RangeSearchAdvanced) searchAndAppend(point pointRankPerm) {
-	var recursivelySearchAndAppend func(node, height int)
-	recursivelySearchAndAppend = func(node, height int) {
-		firstLeafIndex := len(self.xTree) / 2
-		if node >= firstLeafIndex {
-			return
-		}
-		key := self.xTree[node]
-		if point.x <= key {
-			self.bitArrays[node] = append(self.bitArrays[node], 0)
-			self.ballInheritance[node] = append(self.ballInheritance[node], point.i)
-			recursivelySearchAndAppend(2*node+1, height+1)
-		} else {
-			self.bitArrays[node] = append(self.bitArrays[node], 1)
-			self.ballInheritance[node] = append(self.ballInheritance[node], point.i)
-			recursivelySearchAndAppend(2*node+2, height+1)
-		}
-	}
-	root := 0
-	rootHeight := 0
-	recursivelySearchAndAppend(root, rootHeight)
-}
-
-// Helper function for building the bit arrays and ball-inheritance structure.
-func (self *RangeSearchAdvanced) buildRankSelectAndBallInheritance() {
-	self.initializeRankSelectBallInheritance()
-
-	sort.Sort(byYRank(self.pointsRankSpace))
-	for _, p := range self.pointsRankSpace {
-		self.searchAndAppend(p)
-	}
-	numberOfInternalNodes := len(self.xTree) / 2
-	for i := 0; i < numberOfInternalNodes; i++ {
-		if len(self.bitArrays[i]) > 0 {
-			self.rankSelectStructures[i] = gorasp.NewRankSelectFast(self.bitArrays[i])
-		}
-	}
-	sort.Sort(byXRank(self.pointsRankSpace))
+// This function must be called before any Query can be called.
+func (self *RangeSearchAdvancedOf[T]) Build() {
+	if self.rankSpace == nil {
+		self.rankSpace = newRankSpace(self.points, self.compare, 1)
+	}
+	self.index = NewRankSpaceIndex(self.rankSpace.RankPoints())
+	self.index.SetRankSelectFactory(self.rankSelectFactory)
+	self.index.SetLayout(self.layout)
+	self.index.SetBucketSize(self.bucketSize)
+	self.index.SetColors(self.colors)
+	self.index.SetAttributes(self.attributes)
+	self.index.Build()
+}
+
+// Same as Build, but uses up to workers goroutines.
+// If workers is less than 1, runtime.GOMAXPROCS(0) goroutines are used.
+// The resulting structure answers queries exactly like one produced by Build.
+func (self *RangeSearchAdvancedOf[T]) BuildParallel(workers int) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if self.rankSpace == nil {
+		self.rankSpace = newRankSpace(self.points, self.compare, workers)
+	}
+	self.index = NewRankSpaceIndex(self.rankSpace.RankPoints())
+	self.index.SetRankSelectFactory(self.rankSelectFactory)
+	self.index.SetLayout(self.layout)
+	self.index.SetBucketSize(self.bucketSize)
+	self.index.SetColors(self.colors)
+	self.index.SetAttributes(self.attributes)
+	self.index.BuildParallel(workers)
 }
 
-// helper function to initialize all the important arrays.
-func (self *RangeSearchAdvanced) initializeRankSelectBallInheritance() {
-	numberOfInternalNodes := len(self.xTree) / 2
-	self.bitArrays = make([][]int, numberOfInternalNodes)
-	self.rankSelectStructures = make([]gorasp.RankSelect, numberOfInternalNodes)
-	self.ballInheritance = make([][]int, numberOfInternalNodes)
+// Constructor: takes a slice of points. These are the points we want to build the structure on.
+func NewRangeSearchAdvanced(points []Point) *RangeSearchAdvanced {
+	return &RangeSearchAdvanced{*NewRangeSearchAdvancedOf(points)}
 }
 
-// Set the keys of the leaves appropriately.
-func setLeavesOfXTree(xTree []int, pointsRankSpace []pointRankPerm) []int {
-	arrayLength := len(xTree)
-	leafsStartAt := arrayLength / 2
-	for i, p := range pointsRankSpace {
-		index := i + leafsStartAt
-		xTree[index] = p.x
-	}
-
-	leafsEndAt := leafsStartAt + len(pointsRankSpace)
-	noDataValue := -1
-	for i := leafsEndAt; i < len(xTree); i++ {
-		xTree[i] = noDataValue
-	}
-	return xTree
+// Constructor for coordinates with a natural ordering, such as integers, floats and strings.
+func NewRangeSearchAdvancedOf[T cmp.Ordered](points []PointOf[T]) *RangeSearchAdvancedOf[T] {
+	return NewRangeSearchAdvancedFunc(points, cmp.Compare[T])
 }
 
-// Set the keys of internal nodes appropriately.
-// We define the key of an internal node to be the largest key in its left subtree.
-func setInternalNodesOfXTree(xTree []int) []int {
-	var maxSubTree func(n int) int
-	maxSubTree = func(n int) int {
-		rightChild := 2*n + 2
-		if rightChild > len(xTree) {
-			return xTree[n]
-		}
-		return maxSubTree(rightChild)
-	}
-
-	maxLeftSubTree := func(n int) int {
-		leftChild := 2*n + 1
-		if leftChild > len(xTree) {
-			return maxSubTree(n)
-		}
-		return maxSubTree(leftChild)
-	}
-
-	arrayLength := len(xTree)
-	for i := arrayLength/2 - 1; i >= 0; i-- {
-		// put -1 as key of internal nodes where no descendant leaf contains input data.
-		if xTree[2*i+1] == -1 && xTree[2*i+2] == -1 {
-			xTree[i] = -1
-		} else {
-			key := maxLeftSubTree(i)
-			if key != -1 {
-				xTree[i] = key
-			} else {
-				xTree[i] = int(1<<31 - 1)
-			}
-		}
-	}
-
-	return xTree
-}
-
-// Builds the xTree.
-func (self *RangeSearchAdvanced) makeTreeOnXAxis() {
-	arrayLength := 2*getNextPowerOfTwo(len(self.pointsRankSpace)) - 1
-	self.xTree = make([]int, arrayLength)
-	sort.Sort(byXRank(self.pointsRankSpace))
-
-	self.xTree = setLeavesOfXTree(self.xTree, self.pointsRankSpace)
-
-	self.xTree = setInternalNodesOfXTree(self.xTree)
-
-	self.setXTreeHeight()
+// Constructor for coordinates ordered by compare, which must return a negative number, zero or a positive number
+// when a is less than, equal to or greater than b (e.g. time.Time.Compare).
+func NewRangeSearchAdvancedFunc[T any](points []PointOf[T], compare func(a, b T) int) *RangeSearchAdvancedOf[T] {
+	result := new(RangeSearchAdvancedOf[T])
+	result.points = points
+	result.compare = compare
+	result.scanFraction = DefaultScanFraction
+	return result
 }
 
-// compute the height of xTree and set the field xTreeHeight.
-// Height is here defined as the number of nodes from root-to-leaf including both.
-func (self *RangeSearchAdvanced) setXTreeHeight() {
-	arrayLength := len(self.xTree)
-	height := uint(1)
-	for 1<<height < arrayLength {
-		height++
-	}
-	self.xTreeHeight = int(height)
+// Constructor for building on an existing reduction to rank space, which may be shared with other structures.
+// The structure is built on the points the rank space was made from.
+func NewRangeSearchAdvancedWithRankSpace[T any](rankSpace *RankSpaceOf[T]) *RangeSearchAdvancedOf[T] {
+	result := NewRangeSearchAdvancedFunc(rankSpace.points, rankSpace.compare)
+	result.rankSpace = rankSpace
+	return result
 }
 
-// Since our inputs are floats, we reduce everything to rankspace first.
-// This is done by sorting and binary searching each point.
-func (self *RangeSearchAdvanced) makeRankSpace() {
-	xCoords := make([]float64, len(self.points))
-	yCoords := make([]float64, len(self.points))
-	for i, p := range self.points {
-		xCoords[i] = p.x
-		yCoords[i] = p.y
-	}
-	sort.Float64s(xCoords)
-	sort.Float64s(yCoords)
-
-	self.pointsRankSpace = make([]pointRankPerm, len(self.points))
-	for index, p := range self.points {
-		xRank := sort.Search(len(self.points), func(i int) bool { return p.x <= xCoords[i] })
-		yRank := sort.Search(len(self.points), func(i int) bool { return p.y <= yCoords[i] })
-		self.pointsRankSpace[index].x = xRank
-		self.pointsRankSpace[index].y = yRank
-		self.pointsRankSpace[index].i = index
+// Constructor that validates the points before accepting them.
+// It returns ErrNoPoints for an empty slice, a *TooManyPointsError for more than MaxPoints points,
+// and an *InvalidPointError for the first point with a NaN or infinite coordinate.
+// As with NewRangeSearchAdvanced, Build must be called on the result before querying.
+func NewRangeSearchAdvancedE(points []Point) (*RangeSearchAdvanced, error) {
+	if err := validatePoints(points); err != nil {
+		return nil, err
 	}
-	self.xCoords = xCoords
-	self.yCoords = yCoords
-}
-
-// This function must be called before any Query can be called.
-func (self *RangeSearchAdvanced) Build() {
-	self.makeRankSpace()
-	self.makeTreeOnXAxis()
-	self.buildRankSelectAndBallInheritance()
-}
-
-// Constructor: takes a slice of points. These are the points we want to build the structure on.
-func NewRangeSearchAdvanced(points []Point) *RangeSearchAdvanced {
-	result := new(RangeSearchAdvanced)
-	result.points = points
-	return result
+	return NewRangeSearchAdvanced(points), nil
 }
@@ -1,15 +1,19 @@
 package goors
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
 	"os"
 	"runtime/pprof"
+	"sync"
 	"testing"
+	"time"
 )
 
-func testDescend(lca, yLeftExpected, yRightExpected int, structure *RangeSearchAdvanced) bool {
+func testDescend(lca, yLeftExpected, yRightExpected int, structure *RankSpaceIndex) bool {
 	success := true
 	yLeft, yRight := structure.descendToLca(lca, 3, 9)
 	if yLeft != yLeftExpected {
@@ -23,6 +27,16 @@ func testDescend(lca, yLeftExpected, yRightExpected int, structure *RangeSearchA
 	return success
 }
 
+// The bit array of the node in slot, read back from its rank-select structure since the bit arrays are dropped after Build.
+func bitsOf(structure *RankSpaceIndex, slot int) []int {
+	bits := make([]int, len(structure.ballInheritance[slot]))
+	for i := range bits {
+		ranker := structure.rankSelectStructures[slot]
+		bits[i] = ranker.RankOfIndex(i+1) - ranker.RankOfIndex(i)
+	}
+	return bits
+}
+
 func TestSomething(t *testing.T) {
 	// Input drawn on a piece of paper.
 	points := []Point{
@@ -41,11 +55,19 @@ func TestSomething(t *testing.T) {
 	structure := NewRangeSearchAdvanced(points)
 	structure.Build()
 
-	bitArray := structure.bitArrays[1]
+	if structure.index.bitArrays != nil {
+		fmt.Println("expected the bit arrays to be dropped after Build")
+		t.Fail()
+	}
+	bitArray := bitsOf(structure.index, 1)
 	correctBits := []int{0, 1, 0, 1, 1, 1, 0, 0}
+	if len(bitArray) != len(correctBits) {
+		fmt.Println("expected", len(correctBits), "bits, received", len(bitArray))
+		t.Fail()
+	}
 	for i, bit := range bitArray {
 		if bit != correctBits[i] {
-			fmt.Println(i, "th bit incorrect. Expected", bitArray[i], "received", bit)
+			fmt.Println(i, "th bit incorrect. Expected", correctBits[i], "received", bit)
 			t.Fail()
 		}
 	}
@@ -53,7 +75,7 @@ func TestSomething(t *testing.T) {
 	yLeftExpecteds := []int{2, 1, 0, 1}
 	yRightExpecteds := []int{6, 4, 2, 3}
 	for i := 0; i < len(lcas); i++ {
-		if !testDescend(lcas[i], yLeftExpecteds[i], yRightExpecteds[i], structure) {
+		if !testDescend(lcas[i], yLeftExpecteds[i], yRightExpecteds[i], structure.index) {
 			t.Fail()
 		}
 	}
@@ -66,56 +88,6 @@ func TestSomething(t *testing.T) {
 	}
 }
 
-func TestLowestCommonAncestor(t *testing.T) {
-	lefts := []int{1, 3, 4, 8, 9, 19}
-	rights := []int{2, 4, 6, 9, 11, 22}
-	answers := []int{0, 1, 0, 1, 0, 4}
-
-	for i := 0; i < len(lefts); i++ {
-		lca := lowestCommonAncestor(lefts[i], rights[i])
-		if answers[i] != lca {
-			fmt.Println("error: LCA(", lefts[i], ",", rights[i], ") returned", lca, " but expected", answers[i])
-			t.Fail()
-		}
-	}
-}
-
-func TestMakeTreeOnXAxis(t *testing.T) {
-	n := 6
-	points := make([]pointRankPerm, n)
-	for i := 0; i < n; i++ {
-		points[i].x = i
-	}
-
-	ps := []Point{}
-	ds := NewRangeSearchAdvanced(ps)
-
-	ds.pointsRankSpace = points
-	ds.makeTreeOnXAxis()
-	xTree := ds.xTree
-	height := ds.xTreeHeight
-	if height != 4 {
-		fmt.Println("Expected height 4 but received height", height)
-		t.Fail()
-	}
-	noData := -1
-	correctArray := []int{3, 1, 5, 0, 2, 4, noData, 0, 1, 2, 3, 4, 5, noData, noData}
-	correct := true
-	for i, v := range correctArray {
-		if xTree[i] != v {
-			t.Fail()
-			fmt.Println("xTree is not correct")
-			correct = false
-		}
-	}
-	if !correct {
-		fmt.Println("xTree:")
-		fmt.Println(xTree)
-		fmt.Println("correct:")
-		fmt.Println(correctArray)
-	}
-}
-
 func setupFourElements() RangeSearch {
 	points := []Point{{0.0, 0.0}, {5.0, 5.0}, {10.0, 10.0}, {15.0, 15.0}}
 
@@ -348,3 +320,502 @@ func BenchmarkAdvanced(b *testing.B) {
 	fmt.Println("Average output size:", float64(sum)/float64(b.N))
 	result_advanced_test = sum
 }
+
+func TestBuildParallel(t *testing.T) {
+	size := 50000
+	points := make([]Point, size)
+	rand.Seed(7)
+	for i := 0; i < size; i++ {
+		points[i] = Point{rand.Float64(), rand.Float64()}
+	}
+	dsSequential := NewRangeSearchAdvanced(points)
+	dsSequential.Build()
+	dsParallel := NewRangeSearchAdvanced(points)
+	dsParallel.BuildParallel(4)
+
+	for node := range dsSequential.index.ballInheritance {
+		if len(dsSequential.index.ballInheritance[node]) != len(dsParallel.index.ballInheritance[node]) {
+			fmt.Println("ball-inheritance of node", node, "differs in length")
+			t.Fail()
+			continue
+		}
+		sequentialBits := bitsOf(dsSequential.index, node)
+		parallelBits := bitsOf(dsParallel.index, node)
+		for i, bit := range sequentialBits {
+			if parallelBits[i] != bit || dsParallel.index.ballInheritance[node][i] != dsSequential.index.ballInheritance[node][i] {
+				fmt.Println("node", node, "differs at position", i)
+				t.Fail()
+				break
+			}
+		}
+	}
+
+	for i := 0; i < 200; i++ {
+		x1 := float64(rand.Float32())
+		x2 := float64(rand.Float32())
+		y1 := float64(rand.Float32())
+		y2 := float64(rand.Float32())
+		bottomLeft := Point{math.Min(x1, x2), math.Min(y1, y2)}
+		topRight := Point{math.Max(x1, x2), math.Max(y1, y2)}
+		if !sameIndices(dsSequential.Query(bottomLeft, topRight), dsParallel.Query(bottomLeft, topRight)) {
+			fmt.Println("Build and BuildParallel report different elements")
+			t.Fail()
+		}
+	}
+}
+
+func TestParallelSort(t *testing.T) {
+	size := 100003
+	values := make([]float64, size)
+	for i := range values {
+		values[i] = rand.Float64()
+	}
+	parallelSort(values, func(a, b float64) int {
+		if a < b {
+			return -1
+		} else if a > b {
+			return 1
+		}
+		return 0
+	}, 5)
+	for i := 1; i < size; i++ {
+		if values[i-1] > values[i] {
+			fmt.Println("parallelSort did not sort; position", i)
+			t.Fail()
+			break
+		}
+	}
+}
+
+func BenchmarkBuild(b *testing.B) {
+	size := 200000
+	points := make([]Point, size)
+	rand.Seed(42)
+	for i := 0; i < size; i++ {
+		points[i] = Point{float64(rand.Float32() * 100), float64(rand.Float32() * 100)}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ds := NewRangeSearchAdvanced(points)
+		ds.Build()
+	}
+}
+
+func TestQueryBatch(t *testing.T) {
+	size := 20000
+	points := make([]Point, size)
+	rand.Seed(11)
+	for i := 0; i < size; i++ {
+		points[i] = Point{float64(rand.Intn(1000)), float64(rand.Intn(1000))}
+	}
+	ds := NewRangeSearchAdvanced(points)
+	ds.Build()
+	dsSimple := NewRangeSearchSimple(points)
+
+	// a grid of adjacent tiles, plus some random rectangles.
+	rects := []Rect{}
+	for x := 0.0; x < 1000; x += 125 {
+		for y := 0.0; y < 1000; y += 125 {
+			rects = append(rects, MakeRect(Point{x, y}, Point{x + 125, y + 125}))
+		}
+	}
+	for i := 0; i < 100; i++ {
+		x1 := rand.Float64() * 1000
+		x2 := rand.Float64() * 1000
+		y1 := rand.Float64() * 1000
+		y2 := rand.Float64() * 1000
+		rects = append(rects, MakeRect(Point{math.Min(x1, x2), math.Min(y1, y2)}, Point{math.Max(x1, x2), math.Max(y1, y2)}))
+	}
+
+	results := ds.QueryBatch(rects)
+	streamed := make([][]int, len(rects))
+	var mutex sync.Mutex
+	ds.QueryBatchStream(rects, 4, func(i int, result []int) {
+		mutex.Lock()
+		streamed[i] = result
+		mutex.Unlock()
+	})
+	for i, rect := range rects {
+		expected := dsSimple.Query(rect.bottomLeft, rect.topRight)
+		if !sameIndices(expected, results[i]) || !sameIndices(expected, streamed[i]) {
+			fmt.Println("query", i, "expected", len(expected), "results, QueryBatch gave", len(results[i]),
+				"and QueryBatchStream gave", len(streamed[i]), "(or other points)")
+			t.Fail()
+		}
+	}
+}
+
+func TestDescentPath(t *testing.T) {
+	size := 3000
+	points := make([]RankPoint, size)
+	for i, y := range rand.Perm(size) {
+		points[i] = RankPoint{i, y}
+	}
+	for _, layout := range allLayouts {
+		ds := NewRankSpaceIndex(points)
+		ds.SetLayout(layout)
+		ds.Build()
+		path := descentPath{index: ds}
+		yLeft, yRight := 0, 1
+		for i := 0; i < 2000; i++ {
+			// only nodes with points in their left subtree can be an lca.
+			lca := rand.Intn(len(ds.xTree))
+			if key := ds.key(lca); key < 0 || key >= size {
+				continue
+			}
+			// mostly share a bound with the previous call, like a column of tiles.
+			if rand.Intn(4) > 0 && yRight < size {
+				yLeft, yRight = yRight, yRight+1+rand.Intn(size-yRight)
+			} else {
+				yLeft = rand.Intn(size)
+				yRight = yLeft + 1 + rand.Intn(size-yLeft)
+			}
+			expectedLeft, expectedRight := ds.descendToLca(lca, yLeft, yRight)
+			gotLeft, gotRight := path.descend(lca, yLeft, yRight)
+			if gotLeft != expectedLeft || gotRight != expectedRight {
+				fmt.Println(layout, ": descend(", lca, yLeft, yRight, ") =", gotLeft, gotRight, "expected", expectedLeft, expectedRight)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestQueryBeforeBuild(t *testing.T) {
+	ds := NewRangeSearchAdvanced([]Point{{1.0, 1.0}, {2.0, 2.0}})
+	result := ds.Query(Point{0.0, 0.0}, Point{3.0, 3.0})
+	if len(result) != 0 {
+		fmt.Println("Expected no results before Build, but received", len(result))
+		t.Fail()
+	}
+}
+
+func TestQueryRanksBeforeBuild(t *testing.T) {
+	ds := NewRankSpaceIndex([]RankPoint{{0, 1}, {1, 0}})
+	if err := ds.SetColors([]int{1, 2}); err != nil {
+		fmt.Println("SetColors failed:", err)
+		t.Fail()
+	}
+	results := [][]int{
+		ds.QueryRanks(0, 2, 0, 2),
+		ds.QueryRanksOrdered(0, 2, 0, 2, OrderByY),
+		ds.QueryRanksFiltered(0, 2, 0, 2, 0, 0),
+		ds.DistinctColorsRanks(0, 2, 0, 2),
+		ds.SampleRanks(0, 2, 0, 2, 1, rand.New(rand.NewSource(1))),
+	}
+	limited, _ := ds.QueryRanksLimit(0, 2, 0, 2, 1)
+	page, cursor := ds.QueryRanksPage(0, 2, 0, 2, 1, Cursor{})
+	results = append(results, limited, page)
+	for i, result := range results {
+		if len(result) != 0 {
+			fmt.Println("query", i, "expected no results before Build, but received", result)
+			t.Fail()
+		}
+	}
+	if !cursor.Done() {
+		fmt.Println("expected the page to be the last one before Build")
+		t.Fail()
+	}
+	if count := ds.CountRanks(0, 2, 0, 2); count != 0 {
+		fmt.Println("expected count 0 before Build, but received", count)
+		t.Fail()
+	}
+	if index := ds.SelectKthRanks(0, 2, 0, 2, 0, AxisX); index != -1 {
+		fmt.Println("expected no point to select before Build, but received", index)
+		t.Fail()
+	}
+	if _, ok := ds.LowestRanks(0, 2, 0, 2); ok {
+		fmt.Println("expected no lowest point before Build")
+		t.Fail()
+	}
+	if _, ok := ds.HighestRanks(0, 2, 0, 2); ok {
+		fmt.Println("expected no highest point before Build")
+		t.Fail()
+	}
+}
+
+func TestNoPoints(t *testing.T) {
+	ds := NewRangeSearchAdvanced([]Point{})
+	ds.Build()
+	result := ds.Query(Point{0.0, 0.0}, Point{3.0, 3.0})
+	if len(result) != 0 {
+		fmt.Println("Expected no results, but received", len(result))
+		t.Fail()
+	}
+}
+
+// Run with -race to detect data races between concurrent readers.
+func TestConcurrentQueries(t *testing.T) {
+	size := 10000
+	points := make([]Point, size)
+	rand.Seed(3)
+	for i := 0; i < size; i++ {
+		points[i] = Point{rand.Float64(), rand.Float64()}
+	}
+	dsAdvanced := NewRangeSearchAdvanced(points)
+	dsAdvanced.Build()
+	dsSimple := NewRangeSearchSimple(points)
+	dsSimple.Build()
+
+	numberOfGoroutines := 16
+	queriesPerGoroutine := 200
+	var wg sync.WaitGroup
+	failures := make(chan string, numberOfGoroutines)
+	for g := 0; g < numberOfGoroutines; g++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			random := rand.New(rand.NewSource(seed))
+			for i := 0; i < queriesPerGoroutine; i++ {
+				x1, x2 := random.Float64(), random.Float64()
+				y1, y2 := random.Float64(), random.Float64()
+				bottomLeft := Point{math.Min(x1, x2), math.Min(y1, y2)}
+				topRight := Point{math.Max(x1, x2), math.Max(y1, y2)}
+				if !sameIndices(dsSimple.Query(bottomLeft, topRight), dsAdvanced.Query(bottomLeft, topRight)) {
+					failures <- fmt.Sprint("concurrent query ", bottomLeft, topRight, " gave a wrong result")
+					return
+				}
+			}
+		}(int64(g))
+	}
+	wg.Wait()
+	close(failures)
+	for failure := range failures {
+		fmt.Println(failure)
+		t.Fail()
+	}
+}
+
+func TestNewRangeSearchAdvancedE(t *testing.T) {
+	if _, err := NewRangeSearchAdvancedE([]Point{}); err != ErrNoPoints {
+		fmt.Println("Expected ErrNoPoints, but received", err)
+		t.Fail()
+	}
+
+	invalid := []float64{math.NaN(), math.Inf(1), math.Inf(-1)}
+	for _, v := range invalid {
+		points := []Point{{1.0, 2.0}, {3.0, v}, {v, 4.0}}
+		_, err := NewRangeSearchAdvancedE(points)
+		var pointError *InvalidPointError
+		if !errors.As(err, &pointError) || pointError.Index != 1 {
+			fmt.Println("Expected an InvalidPointError for point 1, but received", err)
+			t.Fail()
+		}
+	}
+
+	ds, err := NewRangeSearchAdvancedE([]Point{{1.0, 2.0}, {3.0, 4.0}})
+	if err != nil {
+		fmt.Println("Expected no error, but received", err)
+		t.FailNow()
+	}
+	ds.Build()
+	result, err := ds.QueryE(Point{0.0, 0.0}, Point{2.0, 2.0})
+	if err != nil || len(result) != 1 || result[0] != 0 {
+		fmt.Println("Expected [0], but received", result, err)
+		t.Fail()
+	}
+
+	invalidRects := [][2]Point{
+		{{2.0, 0.0}, {1.0, 5.0}},
+		{{0.0, 2.0}, {5.0, 1.0}},
+		{{math.NaN(), 0.0}, {5.0, 5.0}},
+	}
+	for _, rect := range invalidRects {
+		_, err := ds.QueryE(rect[0], rect[1])
+		var rectError *InvalidRectError
+		if !errors.As(err, &rectError) {
+			fmt.Println("Expected an InvalidRectError for", rect, "but received", err)
+			t.Fail()
+		}
+	}
+}
+
+// Checks that two query results contain the same indices, each exactly once.
+func sameIndices(expected, received []int) bool {
+	if len(expected) != len(received) {
+		return false
+	}
+	counts := make(map[int]int)
+	for _, v := range expected {
+		counts[v]++
+	}
+	for _, v := range received {
+		counts[v]--
+		if counts[v] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDuplicateCoordinates(t *testing.T) {
+	size := 5000
+	points := make([]Point, size)
+	rand.Seed(5)
+	for i := 0; i < size; i++ {
+		// only 20 distinct values per axis, so every coordinate is shared by many points.
+		points[i] = Point{float64(rand.Intn(20)), float64(rand.Intn(20))}
+	}
+	dsAdvanced := NewRangeSearchAdvanced(points)
+	dsAdvanced.Build()
+	dsParallel := NewRangeSearchAdvanced(points)
+	dsParallel.BuildParallel(3)
+	dsSimple := NewRangeSearchSimple(points)
+	dsSimple.Build()
+
+	for i := 0; i < 500; i++ {
+		x1 := float64(rand.Intn(22) - 1)
+		x2 := float64(rand.Intn(22) - 1)
+		y1 := float64(rand.Intn(22) - 1)
+		y2 := float64(rand.Intn(22) - 1)
+		bottomLeft := Point{math.Min(x1, x2), math.Min(y1, y2)}
+		topRight := Point{math.Max(x1, x2), math.Max(y1, y2)}
+		expected := dsSimple.Query(bottomLeft, topRight)
+		if !sameIndices(expected, dsAdvanced.Query(bottomLeft, topRight)) {
+			fmt.Println("Build: wrong result for", bottomLeft, topRight)
+			t.Fail()
+		}
+		if !sameIndices(expected, dsParallel.Query(bottomLeft, topRight)) {
+			fmt.Println("BuildParallel: wrong result for", bottomLeft, topRight)
+			t.Fail()
+		}
+	}
+}
+
+func TestInt64Coordinates(t *testing.T) {
+	// values that cannot all be represented exactly as float64.
+	base := int64(1) << 60
+	points := make([]PointOf[int64], 1000)
+	rand.Seed(13)
+	for i := range points {
+		points[i] = MakePointOf(base+int64(rand.Intn(100)), base+int64(rand.Intn(100)))
+	}
+	dsAdvanced := NewRangeSearchAdvancedOf(points)
+	dsAdvanced.Build()
+	dsSimple := NewRangeSearchSimpleOf(points)
+	dsSimple.Build()
+
+	for i := 0; i < 300; i++ {
+		x1, x2 := base+int64(rand.Intn(100)), base+int64(rand.Intn(100))
+		y1, y2 := base+int64(rand.Intn(100)), base+int64(rand.Intn(100))
+		bottomLeft := MakePointOf(min(x1, x2), min(y1, y2))
+		topRight := MakePointOf(max(x1, x2), max(y1, y2))
+		if !sameIndices(dsSimple.Query(bottomLeft, topRight), dsAdvanced.Query(bottomLeft, topRight)) {
+			fmt.Println("wrong result for", bottomLeft, topRight)
+			t.Fail()
+		}
+	}
+}
+
+func TestTimeCoordinates(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := make([]PointOf[time.Time], 1000)
+	rand.Seed(17)
+	for i := range points {
+		points[i] = MakePointOf(start.Add(time.Duration(rand.Int63n(1000))), start.Add(time.Duration(rand.Int63n(1000))))
+	}
+	dsAdvanced := NewRangeSearchAdvancedFunc(points, time.Time.Compare)
+	dsAdvanced.Build()
+	dsSimple := NewRangeSearchSimpleFunc(points, time.Time.Compare)
+	dsSimple.Build()
+
+	for i := 0; i < 300; i++ {
+		x1, x2 := rand.Int63n(1000), rand.Int63n(1000)
+		y1, y2 := rand.Int63n(1000), rand.Int63n(1000)
+		bottomLeft := MakePointOf(start.Add(time.Duration(min(x1, x2))), start.Add(time.Duration(min(y1, y2))))
+		topRight := MakePointOf(start.Add(time.Duration(max(x1, x2))), start.Add(time.Duration(max(y1, y2))))
+		if !sameIndices(dsSimple.Query(bottomLeft, topRight), dsAdvanced.Query(bottomLeft, topRight)) {
+			fmt.Println("wrong result for", bottomLeft, topRight)
+			t.Fail()
+		}
+	}
+}
+
+func TestScanFraction(t *testing.T) {
+	size := 5000
+	points := make([]Point, size)
+	rand.Seed(31)
+	for i := 0; i < size; i++ {
+		points[i] = Point{float64(rand.Intn(100)), float64(rand.Intn(100))}
+	}
+	dsSimple := NewRangeSearchSimple(points)
+	for _, fraction := range []float64{0.0, 0.1, 0.5, DefaultScanFraction} {
+		dsAdvanced := NewRangeSearchAdvanced(points)
+		dsAdvanced.SetScanFraction(fraction)
+		dsAdvanced.Build()
+		for i := 0; i < 200; i++ {
+			x1, x2 := float64(rand.Intn(110)-5), float64(rand.Intn(110)-5)
+			y1, y2 := float64(rand.Intn(110)-5), float64(rand.Intn(110)-5)
+			bottomLeft := Point{math.Min(x1, x2), math.Min(y1, y2)}
+			topRight := Point{math.Max(x1, x2), math.Max(y1, y2)}
+			if !sameIndices(dsSimple.Query(bottomLeft, topRight), dsAdvanced.Query(bottomLeft, topRight)) {
+				fmt.Println("scan fraction", fraction, "gave a wrong result for", bottomLeft, topRight)
+				t.Fail()
+			}
+		}
+	}
+}
+
+// Compares answering queries of increasing output size through the tree and by scanning.
+func BenchmarkScanFraction(b *testing.B) {
+	size := 1000000
+	points := make([]Point, size)
+	rand.Seed(42)
+	for i := 0; i < size; i++ {
+		points[i] = Point{rand.Float64(), rand.Float64()}
+	}
+	ds := NewRangeSearchAdvanced(points)
+	ds.Build()
+	for _, output := range []float64{0.05, 0.2, 0.4, 0.6, 0.8} {
+		side := math.Sqrt(output)
+		for _, fraction := range []float64{0.0, 1.0} {
+			name := fmt.Sprintf("output=%v/tree", output)
+			if fraction == 0.0 {
+				name = fmt.Sprintf("output=%v/scan", output)
+			}
+			b.Run(name, func(b *testing.B) {
+				ds.SetScanFraction(fraction)
+				for i := 0; i < b.N; i++ {
+					ds.Query(Point{0.0, 0.0}, Point{side, side})
+				}
+			})
+		}
+	}
+}
+
+func TestQueryLimit(t *testing.T) {
+	size := 2000
+	points := make([]Point, size)
+	rand.Seed(43)
+	for i := 0; i < size; i++ {
+		points[i] = Point{float64(rand.Intn(100)), float64(rand.Intn(100))}
+	}
+	structures := []RangeSearch{NewRangeSearchSimple(points), NewRangeSearchAdvanced(points)}
+	for _, ds := range structures {
+		ds.Build()
+	}
+	for i := 0; i < 300; i++ {
+		x1, x2 := float64(rand.Intn(100)), float64(rand.Intn(100))
+		y1, y2 := float64(rand.Intn(100)), float64(rand.Intn(100))
+		bottomLeft := Point{math.Min(x1, x2), math.Min(y1, y2)}
+		topRight := Point{math.Max(x1, x2), math.Max(y1, y2)}
+		expected := structures[0].Query(bottomLeft, topRight)
+		limit := rand.Intn(2*len(expected) + 2)
+		for _, ds := range structures {
+			result, truncated := ds.QueryLimit(bottomLeft, topRight, limit)
+			if truncated != (len(expected) > limit) || len(result) != min(limit, len(expected)) {
+				fmt.Println("QueryLimit with limit", limit, "gave", len(result), truncated, "but there are", len(expected), "points")
+				t.Fail()
+			}
+			// all reported points must be in the rectangle, and distinct.
+			seen := make(map[int]bool)
+			for _, v := range result {
+				if seen[v] || !isContained(cmp.Compare[float64], bottomLeft, topRight, points[v]) {
+					fmt.Println("QueryLimit reported", v, "wrongly")
+					t.Fail()
+				}
+				seen[v] = true
+			}
+		}
+	}
+}
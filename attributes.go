@@ -0,0 +1,120 @@
+package goors
+
+// Gives every point a mask of attributes (e.g. one bit per tag), attributes[i] being the mask of point i, for QueryFiltered.
+// Must be called before Build. Returns ErrAttributeCount unless there is exactly one mask per point, or attributes is nil,
+// which removes the masks.
+func (self *RangeSearchAdvancedOf[T]) SetAttributes(attributes []uint64) error {
+	if attributes != nil && len(attributes) != len(self.points) {
+		return ErrAttributeCount
+	}
+	self.attributes = attributes
+	return nil
+}
+
+// Same as Query, but only reports the points whose mask (see SetAttributes) has all the bits of mustHave and none of mustNotHave.
+// Without masks every point has the mask 0.
+// Runs of points that all fail (or all pass) the filter are skipped (or reported) at once, using the OR and AND of their masks.
+func (self *RangeSearchAdvancedOf[T]) QueryFiltered(bottomLeft, topRight PointOf[T], mustHave, mustNotHave uint64) []int {
+	if self.index == nil {
+		// not built yet.
+		return []int{}
+	}
+	x0, x1, y0, y1 := self.rankSpace.ReduceRect(MakeRectOf(bottomLeft, topRight))
+	return self.index.QueryRanksFiltered(x0, x1, y0, y1, mustHave, mustNotHave)
+}
+
+// Same as RangeSearchAdvancedOf.SetAttributes.
+func (self *RankSpaceIndex) SetAttributes(attributes []uint64) error {
+	if attributes != nil && len(attributes) != len(self.points) {
+		return ErrAttributeCount
+	}
+	self.attributes = attributes
+	return nil
+}
+
+// Same as RangeSearchAdvancedOf.QueryFiltered, for a query in rank space (see QueryRanks).
+func (self *RankSpaceIndex) QueryRanksFiltered(x0, x1, y0, y1 int, mustHave, mustNotHave uint64) []int {
+	if self.attributes == nil {
+		if mustHave != 0 {
+			return []int{}
+		}
+		return self.QueryRanks(x0, x1, y0, y1)
+	}
+	filter := attributeFilter{mustHave, mustNotHave}
+	d := self.decompose(x0, x1, y0, y1, self.descendToLca)
+	result := []int{}
+	for _, p := range d.pieces {
+		if isLeaf(p.node, self) {
+			for _, point := range self.bucket(p.node)[p.yLeft:p.yRight] {
+				if (!p.partial || point.x >= d.x0 && point.x < d.x1) && filter.accepts(self.attributes[point.i]) {
+					result = append(result, point.i)
+				}
+			}
+			continue
+		}
+		ball := self.ballInheritance[p.slot]
+		result = filter.report(self.attributeOrs[p.slot], self.attributeAnds[p.slot], ball, 0, 0, len(ball), p.yLeft, p.yRight, result)
+	}
+	return result
+}
+
+// The predicate of QueryFiltered.
+type attributeFilter struct {
+	mustHave, mustNotHave uint64
+}
+
+func (self attributeFilter) accepts(mask uint64) bool {
+	return mask&self.mustHave == self.mustHave && mask&self.mustNotHave == 0
+}
+
+// Appends the entries of ball in [l, r[ whose masks are accepted to result, looking at tree node v covering [lo, hi[ and below.
+// The OR of a run having a required bit missing, or its AND having a forbidden bit, means no point in it is accepted.
+// The AND having all required bits and the OR no forbidden bits means every point in it is accepted.
+func (self attributeFilter) report(ors, ands []uint64, ball []int, v, lo, hi, l, r int, result []int) []int {
+	if hi <= l || lo >= r || ors[v]&self.mustHave != self.mustHave || ands[v]&self.mustNotHave != 0 {
+		return result
+	}
+	if l <= lo && hi <= r && ands[v]&self.mustHave == self.mustHave && ors[v]&self.mustNotHave == 0 {
+		return append(result, ball[lo:hi]...)
+	}
+	mid := (lo + hi) / 2
+	result = self.report(ors, ands, ball, v+1, lo, mid, l, r, result)
+	return self.report(ors, ands, ball, v+2*(mid-lo), mid, hi, l, r, result)
+}
+
+// Builds the OR and AND trees of every internal node: segment trees over the masks of its ball-inheritance,
+// laid out like the color trees (see buildColorTrees).
+func (self *RankSpaceIndex) buildAttributeTrees(workers int) {
+	self.attributeOrs, self.attributeAnds = nil, nil
+	if self.attributes == nil {
+		return
+	}
+	self.attributeOrs = make([][]uint64, len(self.ballInheritance))
+	self.attributeAnds = make([][]uint64, len(self.ballInheritance))
+	parallelFor(len(self.ballInheritance), workers, func(lo, hi int) {
+		for slot := lo; slot < hi; slot++ {
+			ball := self.ballInheritance[slot]
+			if len(ball) == 0 {
+				continue
+			}
+			self.attributeOrs[slot] = make([]uint64, 2*len(ball)-1)
+			self.attributeAnds[slot] = make([]uint64, 2*len(ball)-1)
+			self.buildAttributeTree(self.attributeOrs[slot], self.attributeAnds[slot], ball, 0, 0, len(ball))
+		}
+	})
+}
+
+// Fills in the OR and AND of the masks of ball[lo:hi] at tree node v and below.
+func (self *RankSpaceIndex) buildAttributeTree(ors, ands []uint64, ball []int, v, lo, hi int) {
+	if hi-lo == 1 {
+		ors[v] = self.attributes[ball[lo]]
+		ands[v] = ors[v]
+		return
+	}
+	mid := (lo + hi) / 2
+	left, right := v+1, v+2*(mid-lo)
+	self.buildAttributeTree(ors, ands, ball, left, lo, mid)
+	self.buildAttributeTree(ors, ands, ball, right, mid, hi)
+	ors[v] = ors[left] | ors[right]
+	ands[v] = ands[left] & ands[right]
+}
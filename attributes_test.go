@@ -0,0 +1,55 @@
+package goors
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestQueryFiltered(t *testing.T) {
+	size := 3000
+	points := make([]Point, size)
+	attributes := make([]uint64, size)
+	rand.Seed(67)
+	for i := 0; i < size; i++ {
+		points[i] = Point{rand.Float64(), rand.Float64()}
+		attributes[i] = uint64(rand.Intn(16))
+		if i%2 == 0 {
+			// bit 4 on every other point, so most filters both reject and accept large parts of the rectangle.
+			attributes[i] |= 16
+		}
+	}
+	filters := [][2]uint64{{0, 0}, {1, 0}, {0, 1}, {3, 4}, {16, 0}, {16, 1}, {0, 16}, {32, 0}}
+
+	for _, bucketSize := range []int{1, 8} {
+		ds := NewRangeSearchAdvanced(points)
+		ds.SetBucketSize(bucketSize)
+		if err := ds.SetAttributes(attributes[1:]); err != ErrAttributeCount {
+			fmt.Println("SetAttributes accepted too few masks")
+			t.Fail()
+		}
+		ds.SetAttributes(attributes)
+		ds.Build()
+
+		for i := 0; i < 100; i++ {
+			x1, x2, y1, y2 := rand.Float64(), rand.Float64(), rand.Float64(), rand.Float64()
+			bottomLeft := Point{math.Min(x1, x2), math.Min(y1, y2)}
+			topRight := Point{math.Max(x1, x2), math.Max(y1, y2)}
+			all := ds.Query(bottomLeft, topRight)
+			for _, filter := range filters {
+				expected := []int{}
+				for _, v := range all {
+					if attributes[v]&filter[0] == filter[0] && attributes[v]&filter[1] == 0 {
+						expected = append(expected, v)
+					}
+				}
+				result := ds.QueryFiltered(bottomLeft, topRight, filter[0], filter[1])
+				if !sameIndices(expected, result) {
+					fmt.Println("QueryFiltered(", bottomLeft, topRight, filter, ") returned", len(result), "points, expected", len(expected))
+					t.Fail()
+				}
+			}
+		}
+	}
+}
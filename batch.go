@@ -0,0 +1,143 @@
+package goors
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
+// QueryBatch answers many queries at once. The i'th slice of the result is what Query would return for rects[i].
+// See QueryBatchStream for how work is shared between the queries.
+func (self *RangeSearchAdvancedOf[T]) QueryBatch(rects []RectOf[T]) [][]int {
+	results := make([][]int, len(rects))
+	self.QueryBatchStream(rects, 1, func(i int, result []int) {
+		results[i] = result
+	})
+	return results
+}
+
+// QueryBatchStream answers the queries in rects, calling emit(i, result) with the answer to rects[i] as soon as it is known.
+// The queries are not answered in the order given: they are sorted by x-range and then by y-range, such that queries with the same
+// lowest common ancestor are processed together, and a column of tiles follows its bounds down to the lca once for each bound
+// rather than once for each query, see descentPath. The rank-space reduction of all the query bounds is done in a single sweep
+// over the sorted coordinates.
+// The sorted queries are split into workers consecutive chunks, each answered by its own goroutine,
+// so when workers > 1 emit is called concurrently and must be safe for that.
+func (self *RangeSearchAdvancedOf[T]) QueryBatchStream(rects []RectOf[T], workers int, emit func(i int, result []int)) {
+	if len(rects) == 0 {
+		return
+	}
+	if self.index == nil {
+		// not built yet.
+		for i := range rects {
+			emit(i, []int{})
+		}
+		return
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	rankRects := self.rankSpace.reduceRects(rects)
+
+	order := make([]int, len(rects))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		ra, rb := rankRects[a], rankRects[b]
+		return cmp.Or(cmp.Compare(ra.x0, rb.x0), cmp.Compare(ra.x1, rb.x1), cmp.Compare(ra.y0, rb.y0), cmp.Compare(ra.y1, rb.y1))
+	})
+
+	answer := func(chunk []int) {
+		path := descentPath{index: self.index}
+		for _, i := range chunk {
+			r := rankRects[i]
+			emit(i, self.index.queryRanks(r.x0, r.x1, r.y0, r.y1, path.descend))
+		}
+	}
+
+	if workers == 1 {
+		answer(order)
+		return
+	}
+	chunkSize := (len(order) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < len(order); lo += chunkSize {
+		hi := min(lo+chunkSize, len(order))
+		wg.Add(1)
+		go func(chunk []int) {
+			defer wg.Done()
+			answer(chunk)
+		}(order[lo:hi])
+	}
+	wg.Wait()
+}
+
+// The root-to-lca path of the previous query, and the ranks of its two y-bounds at each node on it.
+// The next query only computes the ranks below the deepest node its own path has in common with this one,
+// and not at all for a bound it shares with the previous query, which is what adjacent tiles do.
+type descentPath struct {
+	index        *RankSpaceIndex
+	nodes, slots []int // the nodes on the path by depth, and their slots.
+	bounds       [2]boundRanks
+}
+
+// The ranks of the y-rank y (at the root) at each node on a prefix of a descentPath.
+type boundRanks struct {
+	y     int
+	ranks []int
+}
+
+// Same as descendToLca, but reuses what it can of the previous call.
+func (self *descentPath) descend(lca, yLeft, yRight int) (int, int) {
+	depth, _ := depthAndIndexInLevel(lca)
+	common := 0
+	for common < len(self.nodes) && common <= depth && self.nodes[common] == ancestorAtDepth(lca, depth, common) {
+		common++
+	}
+	self.nodes, self.slots = self.nodes[:common], self.slots[:common]
+	for d := common; d <= depth; d++ {
+		node := ancestorAtDepth(lca, depth, d)
+		slot := 0 // the root is in slot 0 in every layout.
+		if d > 0 && node == leftChild(self.nodes[d-1]) {
+			slot = self.index.leftChildSlot(self.slots[d-1])
+		} else if d > 0 {
+			slot = self.index.rightChildSlot(self.slots[d-1])
+		}
+		self.nodes = append(self.nodes, node)
+		self.slots = append(self.slots, slot)
+	}
+
+	// keep the previous bounds equal to yLeft or yRight, and give the buffers of the others to the new bounds.
+	left, right := self.bounds[0], self.bounds[1]
+	if left.y == yRight || right.y == yLeft {
+		left, right = right, left
+	}
+	left = self.reuse(left, yLeft, common)
+	right = self.reuse(right, yRight, common)
+	self.bounds = [2]boundRanks{left, right}
+	return left.ranks[depth], right.ranks[depth]
+}
+
+// Returns the ranks of y at each node on the path, reusing those in bound if it is for the same y and the first common nodes.
+func (self *descentPath) reuse(bound boundRanks, y, common int) boundRanks {
+	if bound.y != y || len(bound.ranks) == 0 {
+		bound = boundRanks{y, append(bound.ranks[:0], y)}
+	} else {
+		bound.ranks = bound.ranks[:min(len(bound.ranks), max(common, 1))]
+	}
+	for d := len(bound.ranks); d < len(self.nodes); d++ {
+		ranker := self.index.rankSelectStructures[self.slots[d-1]]
+		if self.nodes[d] == leftChild(self.nodes[d-1]) {
+			bound.ranks = append(bound.ranks, descendLeft(bound.ranks[d-1], ranker))
+		} else {
+			bound.ranks = append(bound.ranks, descendRight(bound.ranks[d-1], ranker))
+		}
+	}
+	return bound
+}
+
+// The ancestor at depth d of node, which is at the given depth.
+func ancestorAtDepth(node, depth, d int) int {
+	return (node+1)>>uint(depth-d) - 1
+}
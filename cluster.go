@@ -0,0 +1,109 @@
+package goors
+
+import "math"
+
+// Cluster is a group of points shown as one at some zoom level, see Clusterer.
+type Cluster struct {
+	Centroid       Point // the mean of the points in the cluster.
+	Count          int   // the number of points in the cluster.
+	Representative int   // the index of one of the points in the cluster, the one it was grown from.
+}
+
+// Clusterer groups points into clusters for every zoom level of a map, like the supercluster library.
+// At zoom level z, points are merged when they are within radius/2^z of each other (in the units of the coordinates),
+// so the clusters get smaller as the zoom level increases. Above maxZoom every point is its own cluster.
+//
+// The clusters are computed greedily from maxZoom down to minZoom, each level clustering the clusters of the level above:
+// taking the clusters in order, a cluster not yet merged grabs every unmerged cluster whose centroid is within the radius,
+// found by QueryCircle on a RangeSearchAdvanced of the centroids. The clusters of every level are queried the same way.
+type Clusterer struct {
+	points           []Point
+	radius           float64
+	minZoom, maxZoom int
+	levels           []clusterLevel // levels[z-minZoom] holds the clusters for zoom level z, up to and including maxZoom+1.
+}
+
+// The clusters of a zoom level and the structure for querying them by their centroids.
+type clusterLevel struct {
+	clusters []Cluster
+	search   *RangeSearchAdvanced
+}
+
+// Returns the clusters of the given zoom level whose centroids are in the rectangle.
+// Zoom levels below minZoom give the clusters of minZoom, and above maxZoom every point is its own cluster.
+func (self *Clusterer) Clusters(bottomLeft, topRight Point, zoom int) []Cluster {
+	if len(self.levels) == 0 {
+		// not built yet.
+		return []Cluster{}
+	}
+	level := self.levels[min(max(zoom-self.minZoom, 0), len(self.levels)-1)]
+	indices := level.search.Query(bottomLeft, topRight)
+	result := make([]Cluster, len(indices))
+	for i, index := range indices {
+		result[i] = level.clusters[index]
+	}
+	return result
+}
+
+// Computes the clusters of every zoom level. Must be called before Clusters.
+func (self *Clusterer) Build() {
+	numberOfLevels := max(self.maxZoom+2-self.minZoom, 1)
+	self.levels = make([]clusterLevel, numberOfLevels)
+
+	singletons := make([]Cluster, len(self.points))
+	for i, p := range self.points {
+		singletons[i] = Cluster{p, 1, i}
+	}
+	self.levels[numberOfLevels-1] = newClusterLevel(singletons)
+
+	for zoom := self.minZoom + numberOfLevels - 2; zoom >= self.minZoom; zoom-- {
+		above := self.levels[zoom+1-self.minZoom]
+		radius := math.Ldexp(self.radius, -zoom)
+		merged := make([]bool, len(above.clusters))
+		clusters := []Cluster{}
+		for i, seed := range above.clusters {
+			if merged[i] {
+				continue
+			}
+			// the seed is always in its own cluster, even if the circle does not find it.
+			merged[i] = true
+			x, y, count := seed.Centroid.x*float64(seed.Count), seed.Centroid.y*float64(seed.Count), seed.Count
+			for _, j := range above.search.QueryCircle(seed.Centroid, radius) {
+				if merged[j] {
+					continue
+				}
+				merged[j] = true
+				c := above.clusters[j]
+				x += c.Centroid.x * float64(c.Count)
+				y += c.Centroid.y * float64(c.Count)
+				count += c.Count
+			}
+			clusters = append(clusters, Cluster{Point{x / float64(count), y / float64(count)}, count, seed.Representative})
+		}
+		self.levels[zoom-self.minZoom] = newClusterLevel(clusters)
+	}
+}
+
+func newClusterLevel(clusters []Cluster) clusterLevel {
+	centroids := make([]Point, len(clusters))
+	for i, c := range clusters {
+		centroids[i] = c.Centroid
+	}
+	search := NewRangeSearchAdvanced(centroids)
+	search.Build()
+	return clusterLevel{clusters, search}
+}
+
+// Constructor: takes the points to cluster, the radius of a cluster at zoom level 0, and the range of zoom levels to cluster at.
+// A negative or NaN radius is treated as 0, so only points at the same position are merged.
+func NewClusterer(points []Point, radius float64, minZoom, maxZoom int) *Clusterer {
+	result := new(Clusterer)
+	result.points = points
+	if !(radius > 0) {
+		radius = 0
+	}
+	result.radius = radius
+	result.minZoom = minZoom
+	result.maxZoom = maxZoom
+	return result
+}
@@ -0,0 +1,121 @@
+package goors
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestClusterer(t *testing.T) {
+	size := 3000
+	points := make([]Point, size)
+	rand.Seed(97)
+	for i := 0; i < size; i++ {
+		points[i] = Point{rand.Float64(), rand.Float64()}
+	}
+	minZoom, maxZoom := 2, 8
+	clusterer := NewClusterer(points, 0.5, minZoom, maxZoom)
+	clusterer.Build()
+
+	everywhere := func(zoom int) []Cluster {
+		return clusterer.Clusters(Point{-1, -1}, Point{2, 2}, zoom)
+	}
+	if len(everywhere(maxZoom+5)) != size {
+		fmt.Println("above maxZoom every point should be its own cluster")
+		t.Fail()
+	}
+	previous := size
+	for zoom := maxZoom; zoom >= minZoom; zoom-- {
+		clusters := everywhere(zoom)
+		count := 0
+		representatives := make(map[int]bool)
+		for _, c := range clusters {
+			count += c.Count
+			representatives[c.Representative] = true
+		}
+		if count != size || len(representatives) != len(clusters) {
+			fmt.Println("zoom", zoom, "has", len(clusters), "clusters of", count, "points with", len(representatives), "representatives")
+			t.Fail()
+		}
+		if len(clusters) > previous {
+			fmt.Println("zoom", zoom, "has more clusters than the zoom level above")
+			t.Fail()
+		}
+		previous = len(clusters)
+
+		// a rectangle gives the clusters with their centroid inside it.
+		bottomLeft, topRight := Point{0.2, 0.3}, Point{0.6, 0.5}
+		expected := 0
+		for _, c := range clusters {
+			if c.Centroid.X() >= bottomLeft.X() && c.Centroid.X() <= topRight.X() &&
+				c.Centroid.Y() >= bottomLeft.Y() && c.Centroid.Y() <= topRight.Y() {
+				expected++
+			}
+		}
+		if result := clusterer.Clusters(bottomLeft, topRight, zoom); len(result) != expected {
+			fmt.Println("zoom", zoom, "has", len(result), "clusters in the rectangle, expected", expected)
+			t.Fail()
+		}
+	}
+	if len(everywhere(minZoom-3)) != len(everywhere(minZoom)) {
+		fmt.Println("below minZoom the clusters of minZoom should be used")
+		t.Fail()
+	}
+
+	// two tight groups far apart become two clusters with the right centroids.
+	groups := []Point{{0, 0}, {0.001, 0}, {0, 0.001}, {10, 10}, {10.002, 10}}
+	clusterer = NewClusterer(groups, 1, 0, 0)
+	clusterer.Build()
+	clusters := clusterer.Clusters(Point{-1, -1}, Point{11, 11}, 0)
+	if len(clusters) != 2 {
+		fmt.Println("expected 2 clusters, got", clusters)
+		t.Fail()
+		return
+	}
+	for _, c := range clusters {
+		switch c.Count {
+		case 3:
+			if math.Abs(c.Centroid.X()-0.001/3) > 1e-12 || math.Abs(c.Centroid.Y()-0.001/3) > 1e-12 {
+				fmt.Println("wrong centroid", c.Centroid)
+				t.Fail()
+			}
+		case 2:
+			if math.Abs(c.Centroid.X()-10.001) > 1e-12 || c.Centroid.Y() != 10 {
+				fmt.Println("wrong centroid", c.Centroid)
+				t.Fail()
+			}
+		default:
+			fmt.Println("unexpected cluster", c)
+			t.Fail()
+		}
+	}
+}
+
+func TestClusterNonPositiveRadius(t *testing.T) {
+	points := []Point{{1, 1}, {2, 2}, {1, 1}}
+	for _, radius := range []float64{-1, math.NaN(), 0} {
+		clusterer := NewClusterer(points, radius, 0, 2)
+		clusterer.Build()
+		for zoom := 0; zoom <= 3; zoom++ {
+			clusters := clusterer.Clusters(Point{0, 0}, Point{3, 3}, zoom)
+			counts := 0
+			for _, c := range clusters {
+				if c.Count < 1 || math.IsNaN(c.Centroid.X()) || math.IsNaN(c.Centroid.Y()) {
+					fmt.Println("radius", radius, "zoom", zoom, ": invalid cluster", c)
+					t.Fail()
+				}
+				counts += c.Count
+			}
+			// only the two points at the same position are merged, above maxZoom every point is its own cluster.
+			expected := 2
+			if zoom > 2 {
+				expected = 3
+			}
+			if len(clusters) != expected || counts != len(points) {
+				fmt.Println("radius", radius, "zoom", zoom, ": expected", expected, "clusters of", len(points), "points, got", clusters)
+				t.Fail()
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package goors
+
+import "slices"
+
+// Gives every point a color (a category), colors[i] being the color of point i, for DistinctColors and CountDistinct.
+// Must be called before Build. Returns ErrColorCount unless there is exactly one color per point, or colors is nil,
+// which removes the colors.
+func (self *RangeSearchAdvancedOf[T]) SetColors(colors []int) error {
+	if colors != nil && len(colors) != len(self.points) {
+		return ErrColorCount
+	}
+	self.colors = colors
+	return nil
+}
+
+// Returns the distinct colors of the points in the rectangle, in increasing order, see SetColors.
+// Within every piece of the query each color is found once, in O(log n) time, however many points have it,
+// so the time depends on the number of distinct colors rather than the number of points.
+// Returns no colors if SetColors was not called before Build.
+func (self *RangeSearchAdvancedOf[T]) DistinctColors(bottomLeft, topRight PointOf[T]) []int {
+	if self.index == nil {
+		// not built yet.
+		return []int{}
+	}
+	return self.index.DistinctColorsRanks(self.rankSpace.ReduceRect(MakeRectOf(bottomLeft, topRight)))
+}
+
+// Returns the number of distinct colors of the points in the rectangle, see DistinctColors.
+func (self *RangeSearchAdvancedOf[T]) CountDistinct(bottomLeft, topRight PointOf[T]) int {
+	return len(self.DistinctColors(bottomLeft, topRight))
+}
+
+// Same as RangeSearchAdvancedOf.SetColors.
+func (self *RankSpaceIndex) SetColors(colors []int) error {
+	if colors != nil && len(colors) != len(self.points) {
+		return ErrColorCount
+	}
+	self.colors = colors
+	return nil
+}
+
+// Same as RangeSearchAdvancedOf.DistinctColors, for a query in rank space (see QueryRanks).
+func (self *RankSpaceIndex) DistinctColorsRanks(x0, x1, y0, y1 int) []int {
+	if self.colors == nil {
+		return []int{}
+	}
+	d := self.decompose(x0, x1, y0, y1, self.descendToLca)
+	seen := make(map[int]bool)
+	result := []int{}
+	add := func(color int) {
+		if !seen[color] {
+			seen[color] = true
+			result = append(result, color)
+		}
+	}
+	for _, p := range d.pieces {
+		if isLeaf(p.node, self) {
+			for _, point := range self.bucket(p.node)[p.yLeft:p.yRight] {
+				if !p.partial || point.x >= d.x0 && point.x < d.x1 {
+					add(self.colors[point.i])
+				}
+			}
+			continue
+		}
+		ball := self.ballInheritance[p.slot]
+		firstOccurrences(self.colorTrees[p.slot], 0, 0, len(ball), p.yLeft, p.yRight, func(i int) {
+			add(self.colors[ball[i]])
+		})
+	}
+	slices.Sort(result)
+	return result
+}
+
+// Builds the color tree of every internal node.
+// Let prev[i] be the largest j < i such that ball-inheritance entries i and j of the node have the same color, or -1.
+// Then the positions i in [l, r[ with prev[i] < l are exactly the first occurrences of the colors in [l, r[.
+// The color tree is a segment tree of the minimum of prev, with 2m-1 nodes for a node with m points, stored in pre-order:
+// the tree node v covering [lo, hi[ has children v+1 covering [lo, mid[ and v+2(mid-lo) covering [mid, hi[.
+func (self *RankSpaceIndex) buildColorTrees(workers int) {
+	self.colorTrees = nil
+	if self.colors == nil {
+		return
+	}
+	// make the colors dense, so the last occurrences can be kept in a slice.
+	denseColors := make([]int, len(self.colors))
+	ids := make(map[int]int)
+	for i, color := range self.colors {
+		id, ok := ids[color]
+		if !ok {
+			id = len(ids)
+			ids[color] = id
+		}
+		denseColors[i] = id
+	}
+
+	self.colorTrees = make([][]int, len(self.ballInheritance))
+	parallelFor(len(self.ballInheritance), workers, func(lo, hi int) {
+		last := make([]int, len(ids))
+		for slot := lo; slot < hi; slot++ {
+			ball := self.ballInheritance[slot]
+			if len(ball) == 0 {
+				continue
+			}
+			for _, i := range ball {
+				last[denseColors[i]] = -1
+			}
+			prev := make([]int, len(ball))
+			for j, i := range ball {
+				prev[j] = last[denseColors[i]]
+				last[denseColors[i]] = j
+			}
+			tree := make([]int, 2*len(ball)-1)
+			buildMinTree(tree, prev, 0, 0, len(ball))
+			self.colorTrees[slot] = tree
+		}
+	})
+}
+
+// Fills in the minimum of values[lo:hi] at tree node v and below, see buildColorTrees.
+func buildMinTree(tree, values []int, v, lo, hi int) int {
+	if hi-lo == 1 {
+		tree[v] = values[lo]
+		return tree[v]
+	}
+	mid := (lo + hi) / 2
+	tree[v] = min(buildMinTree(tree, values, v+1, lo, mid), buildMinTree(tree, values, v+2*(mid-lo), mid, hi))
+	return tree[v]
+}
+
+// Calls report(i) for every position i in [l, r[ with prev[i] < l, looking at tree node v covering [lo, hi[ and below.
+func firstOccurrences(tree []int, v, lo, hi, l, r int, report func(i int)) {
+	if hi <= l || lo >= r || tree[v] >= l {
+		return
+	}
+	if hi-lo == 1 {
+		report(lo)
+		return
+	}
+	mid := (lo + hi) / 2
+	firstOccurrences(tree, v+1, lo, mid, l, r, report)
+	firstOccurrences(tree, v+2*(mid-lo), mid, hi, l, r, report)
+}
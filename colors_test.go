@@ -0,0 +1,56 @@
+package goors
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestDistinctColors(t *testing.T) {
+	size := 3000
+	points := make([]Point, size)
+	colors := make([]int, size)
+	rand.Seed(61)
+	for i := 0; i < size; i++ {
+		points[i] = Point{rand.Float64(), rand.Float64()}
+		// a few common colors and many rare ones.
+		colors[i] = rand.Intn(5)
+		if rand.Intn(10) == 0 {
+			colors[i] = 100 + rand.Intn(1000)
+		}
+	}
+
+	for _, bucketSize := range []int{1, 8} {
+		ds := NewRangeSearchAdvanced(points)
+		ds.SetBucketSize(bucketSize)
+		if err := ds.SetColors(colors[:10]); err != ErrColorCount {
+			fmt.Println("SetColors accepted too few colors")
+			t.Fail()
+		}
+		if err := ds.SetColors(colors); err != nil {
+			fmt.Println("SetColors failed:", err)
+			t.Fail()
+		}
+		ds.BuildParallel(2)
+
+		for i := 0; i < 200; i++ {
+			x1, x2, y1, y2 := rand.Float64(), rand.Float64(), rand.Float64(), rand.Float64()
+			bottomLeft := Point{math.Min(x1, x2), math.Min(y1, y2)}
+			topRight := Point{math.Max(x1, x2), math.Max(y1, y2)}
+			expected := []int{}
+			for _, v := range ds.Query(bottomLeft, topRight) {
+				if !slices.Contains(expected, colors[v]) {
+					expected = append(expected, colors[v])
+				}
+			}
+			slices.Sort(expected)
+			result := ds.DistinctColors(bottomLeft, topRight)
+			if !slices.Equal(expected, result) || ds.CountDistinct(bottomLeft, topRight) != len(expected) {
+				fmt.Println("DistinctColors(", bottomLeft, topRight, ") =", result, "expected", expected)
+				t.Fail()
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package goors
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The largest number of points a structure can be built on.
+// The x-tree is a complete binary tree, and getNextPowerOfTwo does not go beyond this.
+const MaxPoints = 1 << 30
+
+// ErrNoPoints is returned when trying to construct a structure on no points.
+var ErrNoPoints = errors.New("goors: no points given")
+
+// ErrColorCount is returned when the number of colors given differs from the number of points.
+var ErrColorCount = errors.New("goors: the number of colors differs from the number of points")
+
+// ErrAttributeCount is returned when the number of attribute masks given differs from the number of points.
+var ErrAttributeCount = errors.New("goors: the number of attribute masks differs from the number of points")
+
+// ErrInvalidCursor is returned when unmarshaling a Cursor from text that was not produced by marshaling one.
+var ErrInvalidCursor = errors.New("goors: invalid cursor")
+
+// InvalidPointError is returned when a point has a coordinate that is NaN or infinite.
+// Such coordinates cannot be ordered consistently, so they would corrupt the rank-space reduction.
+type InvalidPointError struct {
+	Index int // index of the offending point in the input.
+	Point Point
+}
+
+func (self *InvalidPointError) Error() string {
+	return fmt.Sprintf("goors: point %d is (%v, %v), but coordinates must be finite", self.Index, self.Point.x, self.Point.y)
+}
+
+// TooManyPointsError is returned when given more than MaxPoints points.
+type TooManyPointsError struct {
+	Count int
+}
+
+func (self *TooManyPointsError) Error() string {
+	return fmt.Sprintf("goors: %d points given, but at most %d are supported", self.Count, MaxPoints)
+}
+
+// InvalidRectError is returned when a query rectangle's bottom-left corner is not below and to the left of its top-right corner
+// (or when one of the corners has a NaN coordinate).
+type InvalidRectError struct {
+	BottomLeft, TopRight Point
+}
+
+func (self *InvalidRectError) Error() string {
+	return fmt.Sprintf("goors: (%v, %v) is not below-left of (%v, %v)",
+		self.BottomLeft.x, self.BottomLeft.y, self.TopRight.x, self.TopRight.y)
+}
+
+// Checks that points can be used to build a structure on.
+func validatePoints(points []Point) error {
+	if len(points) == 0 {
+		return ErrNoPoints
+	}
+	if len(points) > MaxPoints {
+		return &TooManyPointsError{len(points)}
+	}
+	isFinite := func(v float64) bool {
+		return !math.IsNaN(v) && !math.IsInf(v, 0)
+	}
+	for i, p := range points {
+		if !isFinite(p.x) || !isFinite(p.y) {
+			return &InvalidPointError{i, p}
+		}
+	}
+	return nil
+}
+
+// Checks that bottomLeft is below-left of topRight. Equal coordinates are fine, as the rectangle is closed.
+func validateRect(bottomLeft, topRight Point) error {
+	// written such that NaNs fail the test.
+	if !(bottomLeft.x <= topRight.x && bottomLeft.y <= topRight.y) {
+		return &InvalidRectError{bottomLeft, topRight}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package goors
+
+// Returns the point with the lowest y-coordinate among those with x-coordinate in [xMin, xMax] and y-coordinate at least y.
+// Points with the same y-coordinate are ordered by index. The bool is false if there is no such point.
+// Runs in O(log n) time: every piece of the query is sorted by y, so only its first point needs to be looked at.
+func (self *RangeSearchAdvancedOf[T]) LowestAbove(xMin, xMax, y T) (int, bool) {
+	if self.index == nil {
+		// not built yet.
+		return -1, false
+	}
+	x0, _ := self.rankSpace.RankOf(AxisX, xMin)
+	_, x1 := self.rankSpace.RankOf(AxisX, xMax)
+	y0, _ := self.rankSpace.RankOf(AxisY, y)
+	return self.index.LowestRanks(x0, x1, y0, self.rankSpace.Len())
+}
+
+// Returns the point with the highest y-coordinate inside the rectangle, points with the same y-coordinate being ordered by index
+// (so of those, the one with the largest index is returned).
+// The bool is false if the rectangle is empty. Runs in O(log n) time, see LowestAbove.
+func (self *RangeSearchAdvancedOf[T]) HighestInRect(bottomLeft, topRight PointOf[T]) (int, bool) {
+	if self.index == nil {
+		// not built yet.
+		return -1, false
+	}
+	return self.index.HighestRanks(self.rankSpace.ReduceRect(MakeRectOf(bottomLeft, topRight)))
+}
+
+// Returns the point with the lowest y-rank among those QueryRanks would report, and false if there are none.
+func (self *RankSpaceIndex) LowestRanks(x0, x1, y0, y1 int) (int, bool) {
+	return self.extremeRanks(x0, x1, y0, y1, false)
+}
+
+// Returns the point with the highest y-rank among those QueryRanks would report, and false if there are none.
+func (self *RankSpaceIndex) HighestRanks(x0, x1, y0, y1 int) (int, bool) {
+	return self.extremeRanks(x0, x1, y0, y1, true)
+}
+
+// Finds the lowest (or highest) point of every piece of the query and returns the lowest (or highest) of those.
+func (self *RankSpaceIndex) extremeRanks(x0, x1, y0, y1 int, highest bool) (int, bool) {
+	d := self.decompose(x0, x1, y0, y1, self.descendToLca)
+	best := -1
+	better := func(candidate int) bool {
+		if best == -1 {
+			return true
+		}
+		if highest {
+			return self.points[candidate].Y > self.points[best].Y
+		}
+		return self.points[candidate].Y < self.points[best].Y
+	}
+	for _, p := range d.pieces {
+		candidate := -1
+		switch {
+		case isLeaf(p.node, self):
+			// the first (or last) point of the bucket in the y-interval may be outside the x-range of a partial piece.
+			bucket := self.bucket(p.node)[p.yLeft:p.yRight]
+			for i := range bucket {
+				point := bucket[i]
+				if highest {
+					point = bucket[len(bucket)-1-i]
+				}
+				if !p.partial || point.x >= d.x0 && point.x < d.x1 {
+					candidate = point.i
+					break
+				}
+			}
+		case highest:
+			candidate = self.ballInheritance[p.slot][p.yRight-1]
+		default:
+			candidate = self.ballInheritance[p.slot][p.yLeft]
+		}
+		if candidate != -1 && better(candidate) {
+			best = candidate
+		}
+	}
+	return best, best != -1
+}
@@ -0,0 +1,50 @@
+package goors
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestLowestAboveAndHighestInRect(t *testing.T) {
+	size := 2000
+	points := make([]Point, size)
+	rand.Seed(59)
+	for i := 0; i < size; i++ {
+		points[i] = Point{float64(rand.Intn(100)), float64(rand.Intn(100))}
+	}
+	ds := NewRangeSearchAdvanced(points)
+	ds.SetBucketSize(4)
+	ds.Build()
+
+	for i := 0; i < 300; i++ {
+		x1, x2 := float64(rand.Intn(104)-2), float64(rand.Intn(104)-2)
+		y1, y2 := float64(rand.Intn(104)-2), float64(rand.Intn(104)-2)
+		bottomLeft := Point{math.Min(x1, x2), math.Min(y1, y2)}
+		topRight := Point{math.Max(x1, x2), math.Max(y1, y2)}
+
+		// the expected answers by scanning, ties broken by index.
+		lowest, highest := -1, -1
+		for j, p := range points {
+			if p.x < bottomLeft.x || p.x > topRight.x || p.y < bottomLeft.y {
+				continue
+			}
+			if lowest == -1 || p.y < points[lowest].y {
+				lowest = j
+			}
+			if p.y <= topRight.y && (highest == -1 || p.y >= points[highest].y) {
+				highest = j
+			}
+		}
+
+		if got, ok := ds.LowestAbove(bottomLeft.x, topRight.x, bottomLeft.y); got != lowest || ok != (lowest != -1) {
+			fmt.Println("LowestAbove(", bottomLeft.x, topRight.x, bottomLeft.y, ") =", got, ok, "expected", lowest)
+			t.Fail()
+		}
+		if got, ok := ds.HighestInRect(bottomLeft, topRight); got != highest || ok != (highest != -1) {
+			fmt.Println("HighestInRect(", bottomLeft, topRight, ") =", got, ok, "expected", highest)
+			t.Fail()
+		}
+	}
+}
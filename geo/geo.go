@@ -0,0 +1,105 @@
+// Package geo searches points given by latitude and longitude, handling query boxes that cross the antimeridian
+// and queries by distance, on top of goors.RangeSearchAdvanced.
+package geo
+
+import (
+	"math"
+
+	"github.com/jasn/goors"
+)
+
+// The mean radius of the Earth in meters, used by Distance.
+const EarthRadius = 6371008.8
+
+// LatLng is a position on the Earth in degrees. Latitudes are in [-90, 90], longitudes may be given in any range.
+type LatLng struct {
+	Lat, Lng float64
+}
+
+// Index answers box and distance queries on a set of positions.
+// The longitude is the x-coordinate and the latitude the y-coordinate of the points of the underlying structure.
+// The rules for concurrency are those of goors.RangeSearchAdvanced: build once, then query from any number of goroutines.
+type Index struct {
+	points []LatLng
+	search *goors.RangeSearchAdvanced
+}
+
+// Query reports the positions in the box from southWest to northEast.
+// The box goes east from southWest.Lng to northEast.Lng, so if northEast.Lng is west of southWest.Lng
+// (e.g. from 170 to -170) the box crosses the antimeridian, and it is answered by two queries, one on either side.
+func (self *Index) Query(southWest, northEast LatLng) []int {
+	west, east := normalizeLng(southWest.Lng), normalizeLng(northEast.Lng)
+	if northEast.Lng-southWest.Lng >= 360 {
+		west, east = -180, 180
+	}
+	south, north := southWest.Lat, northEast.Lat
+	if west <= east {
+		return self.search.Query(goors.MakePoint(west, south), goors.MakePoint(east, north))
+	}
+	// longitudes are normalized to [-180, 180[, so the two parts are disjoint and no position is reported twice.
+	result := self.search.Query(goors.MakePoint(west, south), goors.MakePoint(180, north))
+	return append(result, self.search.Query(goors.MakePoint(-180, south), goors.MakePoint(east, north))...)
+}
+
+// QueryRadius reports the positions at most meters from center, measured along the surface of the Earth (see Distance).
+// The positions in a box around the circle are found with Query and then tested one by one.
+func (self *Index) QueryRadius(center LatLng, meters float64) []int {
+	if !(meters >= 0) {
+		return []int{}
+	}
+	angle := meters / EarthRadius // in radians.
+	south := center.Lat - angle*180/math.Pi
+	north := center.Lat + angle*180/math.Pi
+	west, east := -180.0, 180.0
+	if south > -90 && north < 90 && angle < math.Pi/2 {
+		// the circle does not contain a pole, so it spans less than all longitudes.
+		// The widest part is not at the latitude of the center but where the circle touches the meridians it spans.
+		sinLng := math.Sin(angle) / math.Cos(center.Lat*math.Pi/180)
+		if sinLng < 1 {
+			deltaLng := math.Asin(sinLng) * 180 / math.Pi
+			west, east = center.Lng-deltaLng, center.Lng+deltaLng
+		}
+	}
+	result := []int{}
+	for _, i := range self.Query(LatLng{math.Max(south, -90), west}, LatLng{math.Min(north, 90), east}) {
+		if Distance(center, self.points[i]) <= meters {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// Must be called before any query.
+func (self *Index) Build() {
+	self.search.Build()
+}
+
+// Distance returns the great-circle distance in meters between a and b, computed with the haversine formula.
+func Distance(a, b LatLng) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadius * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
+
+// Returns lng moved into [-180, 180[ by adding or subtracting a multiple of 360.
+func normalizeLng(lng float64) float64 {
+	lng = math.Mod(lng+180, 360)
+	if lng < 0 {
+		lng += 360
+	}
+	return lng - 180
+}
+
+// Constructor: takes the positions to search. Indices reported by queries refer to this slice.
+func NewIndex(points []LatLng) *Index {
+	result := new(Index)
+	result.points = points
+	normalized := make([]goors.Point, len(points))
+	for i, p := range points {
+		normalized[i] = goors.MakePoint(normalizeLng(p.Lng), p.Lat)
+	}
+	result.search = goors.NewRangeSearchAdvanced(normalized)
+	return result
+}
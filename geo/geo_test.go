@@ -0,0 +1,93 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func randomPoints(n int) []LatLng {
+	points := make([]LatLng, n)
+	for i := range points {
+		// longitudes outside [-180, 180[ on purpose, they must be normalized.
+		points[i] = LatLng{rand.Float64()*180 - 90, rand.Float64()*720 - 360}
+	}
+	return points
+}
+
+func TestQueryAcrossAntimeridian(t *testing.T) {
+	rand.Seed(79)
+	points := randomPoints(5000)
+	index := NewIndex(points)
+	index.Build()
+
+	for i := 0; i < 200; i++ {
+		west := rand.Float64()*360 - 180
+		width := rand.Float64() * 100
+		south := rand.Float64()*180 - 90
+		north := math.Min(south+rand.Float64()*60, 90)
+		expected := []int{}
+		for j, p := range points {
+			// the distance east from west to the point.
+			east := normalizeLng(p.Lng-west-180) + 180
+			if p.Lat >= south && p.Lat <= north && east <= width {
+				expected = append(expected, j)
+			}
+		}
+		result := index.Query(LatLng{south, west}, LatLng{north, west + width})
+		slices.Sort(result)
+		if !slices.Equal(expected, result) {
+			fmt.Println("Query from", west, "to", west+width, "returned", len(result), "points, expected", len(expected))
+			t.Fail()
+		}
+	}
+
+	// the whole world.
+	if len(index.Query(LatLng{-90, -180}, LatLng{90, 180})) != len(points) {
+		fmt.Println("Query of the whole world did not return every point")
+		t.Fail()
+	}
+}
+
+func TestQueryRadius(t *testing.T) {
+	rand.Seed(83)
+	points := randomPoints(5000)
+	index := NewIndex(points)
+	index.Build()
+
+	centers := []LatLng{{0, 179.9}, {0, -179.9}, {89, 0}, {-89.9, 45}}
+	for i := 0; i < 100; i++ {
+		centers = append(centers, LatLng{rand.Float64()*180 - 90, rand.Float64()*360 - 180})
+	}
+	for _, center := range centers {
+		meters := rand.Float64() * 3000000
+		expected := []int{}
+		for j, p := range points {
+			if Distance(center, p) <= meters {
+				expected = append(expected, j)
+			}
+		}
+		result := index.QueryRadius(center, meters)
+		slices.Sort(result)
+		if !slices.Equal(expected, result) {
+			fmt.Println("QueryRadius(", center, meters, ") returned", len(result), "points, expected", len(expected))
+			t.Fail()
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	// a quarter of the way around the equator.
+	quarter := Distance(LatLng{0, 0}, LatLng{0, 90})
+	if math.Abs(quarter-math.Pi*EarthRadius/2) > 1e-6 {
+		fmt.Println("Distance of a quarter of the equator is", quarter)
+		t.Fail()
+	}
+	// across the antimeridian.
+	if d := Distance(LatLng{0, 179.5}, LatLng{0, -179.5}); math.Abs(d-Distance(LatLng{0, 0}, LatLng{0, 1})) > 1e-6 {
+		fmt.Println("Distance across the antimeridian is", d)
+		t.Fail()
+	}
+}
@@ -0,0 +1,49 @@
+package goors
+
+// Returns the number of points in each cell of a grid of cols by rows equally sized cells covering the rectangle.
+// The result is indexed by row and then column, row 0 being at the bottom and column 0 at the left.
+// A point on the boundary between two cells is counted in the upper (or right) one, and the cells on the top
+// (or right) side of the grid include the top (or right) side of the rectangle, so every point is counted once.
+//
+// The rank of every grid line is computed once, after which the count of every cell follows from the number of
+// points below-left of each of the (cols+1)(rows+1) grid crossings, taking O(cols·rows·log n) time in total.
+func (self *RangeSearchAdvanced) Histogram(bottomLeft, topRight Point, cols, rows int) [][]int {
+	if cols < 1 || rows < 1 {
+		return [][]int{}
+	}
+	result := make([][]int, rows)
+	for r := range result {
+		result[r] = make([]int, cols)
+	}
+	if self.index == nil || !(bottomLeft.x <= topRight.x && bottomLeft.y <= topRight.y) {
+		return result
+	}
+
+	xRanks := gridRanks(self.rankSpace, AxisX, bottomLeft.x, topRight.x, cols)
+	yRanks := gridRanks(self.rankSpace, AxisY, bottomLeft.y, topRight.y, rows)
+	// below[r][c] is the number of points with x-rank less than xRanks[c] and y-rank less than yRanks[r].
+	below := make([][]int, rows+1)
+	for r := range below {
+		below[r] = make([]int, cols+1)
+		for c := range below[r] {
+			below[r][c] = self.index.CountRanks(0, xRanks[c], 0, yRanks[r])
+		}
+	}
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			result[r][c] = below[r+1][c+1] - below[r+1][c] - below[r][c+1] + below[r][c]
+		}
+	}
+	return result
+}
+
+// Returns the ranks of the cells+1 lines cutting [lo, hi] on axis into cells equally sized intervals.
+// The first cells lines are the lowest rank at their coordinate, and the last is one past the highest rank at hi.
+func gridRanks(rankSpace *RankSpace, axis Axis, lo, hi float64, cells int) []int {
+	ranks := make([]int, cells+1)
+	for i := 0; i < cells; i++ {
+		ranks[i], _ = rankSpace.RankOf(axis, lo+(hi-lo)*float64(i)/float64(cells))
+	}
+	_, ranks[cells] = rankSpace.RankOf(axis, hi)
+	return ranks
+}
@@ -0,0 +1,64 @@
+package goors
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestHistogram(t *testing.T) {
+	size := 3000
+	points := make([]Point, size)
+	rand.Seed(89)
+	for i := 0; i < size; i++ {
+		// integer coordinates, so plenty of points are on grid lines.
+		points[i] = Point{float64(rand.Intn(40)), float64(rand.Intn(40))}
+	}
+	ds := NewRangeSearchAdvanced(points)
+	ds.Build()
+
+	for i := 0; i < 100; i++ {
+		x1, x2 := float64(rand.Intn(44)-2), float64(rand.Intn(44)-2)
+		y1, y2 := float64(rand.Intn(44)-2), float64(rand.Intn(44)-2)
+		bottomLeft := Point{math.Min(x1, x2), math.Min(y1, y2)}
+		topRight := Point{math.Max(x1, x2), math.Max(y1, y2)}
+		cols, rows := 1+rand.Intn(8), 1+rand.Intn(8)
+
+		// the cell of a point, computed the same way as the grid lines.
+		cell := func(v, lo, hi float64, cells int) int {
+			for c := cells - 1; c > 0; c-- {
+				if v >= lo+(hi-lo)*float64(c)/float64(cells) {
+					return c
+				}
+			}
+			return 0
+		}
+		expected := make([][]int, rows)
+		for r := range expected {
+			expected[r] = make([]int, cols)
+		}
+		total := 0
+		for _, index := range ds.Query(bottomLeft, topRight) {
+			p := points[index]
+			expected[cell(p.y, bottomLeft.y, topRight.y, rows)][cell(p.x, bottomLeft.x, topRight.x, cols)]++
+			total++
+		}
+
+		result := ds.Histogram(bottomLeft, topRight, cols, rows)
+		sum := 0
+		for r := range expected {
+			for c := range expected[r] {
+				sum += result[r][c]
+				if result[r][c] != expected[r][c] {
+					fmt.Println("Histogram(", bottomLeft, topRight, cols, rows, ") cell", r, c, "is", result[r][c], "expected", expected[r][c])
+					t.Fail()
+				}
+			}
+		}
+		if sum != total {
+			fmt.Println("Histogram counted", sum, "points, but there are", total)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package goors
+
+import "math/bits"
+
+// TreeLayout decides where the nodes of the x-tree are stored in memory.
+// The layout maps an internal node to the slot holding its key, bit array, rank structure and ball-inheritance,
+// and the bit arrays and ball-inheritance themselves are allocated in slot order.
+// Only the internal nodes are laid out: they take up exactly the first slots, and the leaves follow in BFS order,
+// so the per-node arrays need no slots for the leaves, which carry none of that data.
+// Queries navigate from slot to slot with precomputed child slots, see setChildSlots.
+type TreeLayout int
+
+const (
+	// Nodes are stored in BFS order, so a node's slot is its number. This is the default.
+	LayoutBFS TreeLayout = iota
+	// Recursive van Emde Boas layout: the tree is cut at half its height, and the top tree is stored
+	// followed by each of the bottom trees, all laid out recursively. Cache-oblivious.
+	LayoutVanEmdeBoas
+	// The binary tree is cut into complete subtrees of height 3 (7 nodes, 8 children each), which are stored in BFS order
+	// of the resulting 8-ary tree, each subtree contiguously.
+	LayoutEytzinger8
+	// As LayoutEytzinger8, but with subtrees of height 4 (15 nodes, 16 children each).
+	LayoutEytzinger16
+)
+
+func (self TreeLayout) String() string {
+	switch self {
+	case LayoutBFS:
+		return "BFS"
+	case LayoutVanEmdeBoas:
+		return "VanEmdeBoas"
+	case LayoutEytzinger8:
+		return "Eytzinger8"
+	case LayoutEytzinger16:
+		return "Eytzinger16"
+	}
+	return "unknown"
+}
+
+// Returns the slot of node in a tree with the given number of levels.
+func (self TreeLayout) position(node, height int) int {
+	switch self {
+	case LayoutVanEmdeBoas:
+		return vanEmdeBoasPosition(node, height)
+	case LayoutEytzinger8:
+		return blockedPosition(node, height, 3)
+	case LayoutEytzinger16:
+		return blockedPosition(node, height, 4)
+	}
+	return node
+}
+
+// Returns the number of slots needed to lay out a tree with the given number of levels.
+// All the layouts are dense, so this is the number of nodes.
+func (self TreeLayout) size(height int) int {
+	return 1<<uint(height) - 1
+}
+
+// The depth of node (the root has depth 0) and its index among the nodes of that depth, from left to right.
+func depthAndIndexInLevel(node int) (int, int) {
+	depth := bits.Len(uint(node+1)) - 1
+	return depth, node + 1 - 1<<uint(depth)
+}
+
+// Slot of node in the van Emde Boas layout of a complete tree with the given number of levels.
+// The top tree gets the upper half of the levels (rounded down) and is stored first, then the bottom trees from left to right.
+func vanEmdeBoasPosition(node, height int) int {
+	depth, indexInLevel := depthAndIndexInLevel(node)
+	position := 0
+	for height > 1 {
+		topHeight := height / 2
+		bottomHeight := height - topHeight
+		if depth < topHeight {
+			height = topHeight
+			continue
+		}
+		bottomTree := indexInLevel >> uint(depth-topHeight)
+		position += (1<<uint(topHeight) - 1) + bottomTree*(1<<uint(bottomHeight)-1)
+		depth -= topHeight
+		indexInLevel &= 1<<uint(depth) - 1
+		height = bottomHeight
+	}
+	return position
+}
+
+// Slot of node when a tree with the given number of levels is cut into complete subtrees of blockHeight levels,
+// stored in BFS order of the subtrees. The subtrees at the bottom may have fewer levels, and then they take up only as many slots.
+func blockedPosition(node, height, blockHeight int) int {
+	depth, indexInLevel := depthAndIndexInLevel(node)
+	blockSize := 1<<uint(blockHeight) - 1
+	blockRootDepth := depth - depth%blockHeight
+	depthInBlock := depth - blockRootDepth
+	levelBlockSize := 1<<uint(min(blockHeight, height-blockRootDepth)) - 1
+
+	// there are 2^(blockHeight*t) blocks on block level t, and the sum of those for t below this level.
+	// Those are all full, only the blocks on the last block level can be smaller.
+	blocksBefore := (1<<uint(blockRootDepth) - 1) / blockSize
+	local := (1<<uint(depthInBlock) - 1) + indexInLevel&(1<<uint(depthInBlock)-1)
+	return blocksBefore*blockSize + indexInLevel>>uint(depthInBlock)*levelBlockSize + local
+}
+
+func leftChild(node int) int {
+	return 2*node + 1
+}
+
+func rightChild(node int) int {
+	return 2*node + 2
+}
+
+// The slot of node in the arrays of the x-tree.
+// The internal nodes are laid out in the first firstLeaf slots, and the leaves follow in BFS order, so their slot is their number.
+func (self *RankSpaceIndex) slot(node int) int {
+	if self.layout == LayoutBFS || node >= self.firstLeaf {
+		return node
+	}
+	return self.layout.position(node, self.xTreeHeight-1)
+}
+
+// The key of node, see setInternalNodesOfXTree.
+func (self *RankSpaceIndex) key(node int) int {
+	return self.xTree[self.slot(node)]
+}
+
+// The slot of the left child of the internal node stored in slot.
+func (self *RankSpaceIndex) leftChildSlot(slot int) int {
+	return self.childSlots[2*slot]
+}
+
+// The slot of the right child of the internal node stored in slot.
+func (self *RankSpaceIndex) rightChildSlot(slot int) int {
+	return self.childSlots[2*slot+1]
+}
+
+// Computes the slots of the children of each internal node, such that queries can navigate the tree without computing positions.
+func (self *RankSpaceIndex) setChildSlots() {
+	self.childSlots = make([]int, 2*self.firstLeaf)
+	for node := 0; node < self.firstLeaf; node++ {
+		slot := self.slot(node)
+		self.childSlots[2*slot] = self.slot(leftChild(node))
+		self.childSlots[2*slot+1] = self.slot(rightChild(node))
+	}
+}
+
+// The leaf holding the point with the given x-rank.
+func (self *RankSpaceIndex) leafOf(xRank int) int {
+	return self.firstLeaf + xRank/self.bucketSize
+}
+
+// The points of leaf, by increasing y-rank. Empty for leaves past the last point.
+func (self *RankSpaceIndex) bucket(leaf int) []pointRankPerm {
+	start := (leaf - self.firstLeaf) * self.bucketSize
+	if start >= len(self.pointsRankSpace) {
+		return nil
+	}
+	end := min(start+self.bucketSize, len(self.pointsRankSpace))
+	return self.pointsRankSpace[start:end]
+}
+
+// The x-ranks [lo, hi[ of the points below node, which are those of the leaves in its subtree.
+func (self *RankSpaceIndex) xRanksBelow(node int) (int, int) {
+	depth, indexInLevel := depthAndIndexInLevel(node)
+	leaves := 1 << uint(self.xTreeHeight-1-depth)
+	lo := indexInLevel * leaves * self.bucketSize
+	hi := min(lo+leaves*self.bucketSize, len(self.pointsRankSpace))
+	return lo, hi
+}
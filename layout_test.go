@@ -0,0 +1,103 @@
+package goors
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+var allLayouts = []TreeLayout{LayoutBFS, LayoutVanEmdeBoas, LayoutEytzinger8, LayoutEytzinger16}
+
+func TestLayoutPositionsAreDistinct(t *testing.T) {
+	for _, layout := range allLayouts {
+		for height := 1; height <= 12; height++ {
+			size := layout.size(height)
+			if size != 1<<uint(height)-1 {
+				fmt.Println(layout, "height", height, ": needs", size, "slots for", 1<<uint(height)-1, "nodes")
+				t.Fail()
+			}
+			used := make([]bool, size)
+			for node := 0; node < 1<<uint(height)-1; node++ {
+				position := layout.position(node, height)
+				if position < 0 || position >= size || used[position] {
+					fmt.Println(layout, "height", height, ": node", node, "got slot", position, "of", size)
+					t.Fail()
+					break
+				}
+				used[position] = true
+			}
+		}
+	}
+}
+
+func TestVanEmdeBoasPosition(t *testing.T) {
+	// height 4: top tree of 2 levels (nodes 0, 1, 2), then four bottom trees of 2 levels each.
+	expected := []int{0, 1, 2, 3, 6, 9, 12, 4, 5, 7, 8, 10, 11, 13, 14}
+	for node, position := range expected {
+		if vanEmdeBoasPosition(node, 4) != position {
+			fmt.Println("vanEmdeBoasPosition(", node, ", 4) =", vanEmdeBoasPosition(node, 4), "expected", position)
+			t.Fail()
+		}
+	}
+}
+
+func TestLayouts(t *testing.T) {
+	size := 5000
+	points := make([]Point, size)
+	rand.Seed(31)
+	for i := 0; i < size; i++ {
+		points[i] = Point{float64(rand.Intn(500)), float64(rand.Intn(500))}
+	}
+	dsSimple := NewRangeSearchSimple(points)
+	dsSimple.Build()
+	structures := []*RangeSearchAdvanced{}
+	for _, layout := range allLayouts {
+		ds := NewRangeSearchAdvanced(points)
+		ds.SetLayout(layout)
+		ds.Build()
+		structures = append(structures, ds)
+		ds = NewRangeSearchAdvanced(points)
+		ds.SetLayout(layout)
+		ds.BuildParallel(2)
+		structures = append(structures, ds)
+	}
+
+	for i := 0; i < 300; i++ {
+		x1, x2 := rand.Float64()*500, rand.Float64()*500
+		y1, y2 := rand.Float64()*500, rand.Float64()*500
+		bottomLeft := Point{math.Min(x1, x2), math.Min(y1, y2)}
+		topRight := Point{math.Max(x1, x2), math.Max(y1, y2)}
+		expected := dsSimple.Query(bottomLeft, topRight)
+		for j, ds := range structures {
+			if !sameIndices(expected, ds.Query(bottomLeft, topRight)) {
+				fmt.Println(allLayouts[j/2], ": wrong result for", bottomLeft, topRight)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func BenchmarkLayouts(b *testing.B) {
+	size := 1 << 20
+	points := make([]Point, size)
+	rand.Seed(42)
+	for i := 0; i < size; i++ {
+		points[i] = Point{rand.Float64() * 100, rand.Float64() * 100}
+	}
+	for _, layout := range allLayouts {
+		ds := NewRangeSearchAdvanced(points)
+		ds.SetLayout(layout)
+		ds.BuildParallel(0)
+		b.Run(layout.String(), func(b *testing.B) {
+			sum := 0
+			for i := 0; i < b.N; i++ {
+				// small queries, so the time is dominated by navigating the tree rather than reporting.
+				x := rand.Float64() * 99
+				y := rand.Float64() * 99
+				sum += len(ds.Query(Point{x, y}, Point{x + 1, y + 1}))
+			}
+			result_advanced_test = sum
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package goors
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Order is the order in which QueryOrdered reports the points.
+type Order int
+
+const (
+	// By position in the slice of points given to the constructor.
+	OrderByIndex Order = iota
+	// By increasing x-coordinate, points with the same x-coordinate by index.
+	OrderByX
+	// By increasing y-coordinate, points with the same y-coordinate by index.
+	OrderByY
+)
+
+func (self Order) String() string {
+	switch self {
+	case OrderByIndex:
+		return "ByIndex"
+	case OrderByX:
+		return "ByX"
+	case OrderByY:
+		return "ByY"
+	}
+	return "unknown"
+}
+
+// Same as Query, but the result is sorted according to order.
+func (self *RangeSearchAdvancedOf[T]) QueryOrdered(bottomLeft, topRight PointOf[T], order Order) []int {
+	if self.index == nil {
+		// not built yet.
+		return []int{}
+	}
+	x0, x1, y0, y1 := self.rankSpace.ReduceRect(MakeRectOf(bottomLeft, topRight))
+	return self.index.QueryRanksOrdered(x0, x1, y0, y1, order)
+}
+
+// Same as QueryRanks, but the result is sorted according to order (OrderByX and OrderByY sort by rank).
+// Each piece of the query is reported as a run. The runs are sorted by y-rank already, and ordered by x-rank among themselves,
+// so sorting by y is a merge of the O(log n) runs, and sorting by x only has to sort within each run.
+func (self *RankSpaceIndex) QueryRanksOrdered(x0, x1, y0, y1 int, order Order) []int {
+	d := self.decompose(x0, x1, y0, y1, self.descendToLca)
+	result := make([]int, 0, self.count(d))
+	runs := make([]int, 0, len(d.pieces)+1)
+	for _, p := range d.pieces {
+		runs = append(runs, len(result))
+		result = self.reportPiece(result, d, p)
+	}
+	runs = append(runs, len(result))
+
+	switch order {
+	case OrderByIndex:
+		slices.Sort(result)
+	case OrderByX:
+		byX := func(a, b int) int { return cmp.Compare(self.points[a].X, self.points[b].X) }
+		for r := 0; r+1 < len(runs); r++ {
+			slices.SortFunc(result[runs[r]:runs[r+1]], byX)
+		}
+	case OrderByY:
+		byY := func(a, b int) int { return cmp.Compare(self.points[a].Y, self.points[b].Y) }
+		result = mergeSortedRuns(result, runs, byY, 1)
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package goors
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestQueryOrdered(t *testing.T) {
+	size := 3000
+	points := make([]Point, size)
+	rand.Seed(37)
+	for i := 0; i < size; i++ {
+		// few distinct coordinates, so there are plenty of ties.
+		points[i] = Point{float64(rand.Intn(50)), float64(rand.Intn(50))}
+	}
+	dsSimple := NewRangeSearchSimple(points)
+	dsAdvanced := NewRangeSearchAdvanced(points)
+	dsAdvanced.SetBucketSize(4)
+	dsAdvanced.Build()
+
+	// a comes strictly before b in order.
+	before := func(a, b int, order Order) bool {
+		switch order {
+		case OrderByX:
+			return points[a].x < points[b].x || points[a].x == points[b].x && a < b
+		case OrderByY:
+			return points[a].y < points[b].y || points[a].y == points[b].y && a < b
+		}
+		return a < b
+	}
+
+	for i := 0; i < 300; i++ {
+		x1, x2 := float64(rand.Intn(55)-2), float64(rand.Intn(55)-2)
+		y1, y2 := float64(rand.Intn(55)-2), float64(rand.Intn(55)-2)
+		bottomLeft := Point{math.Min(x1, x2), math.Min(y1, y2)}
+		topRight := Point{math.Max(x1, x2), math.Max(y1, y2)}
+		expected := dsSimple.Query(bottomLeft, topRight)
+		for _, order := range []Order{OrderByIndex, OrderByX, OrderByY} {
+			result := dsAdvanced.QueryOrdered(bottomLeft, topRight, order)
+			if !sameIndices(expected, result) {
+				fmt.Println("QueryOrdered", order, "reported the wrong points for", bottomLeft, topRight)
+				t.Fail()
+				continue
+			}
+			for j := 1; j < len(result); j++ {
+				if !before(result[j-1], result[j], order) {
+					fmt.Println("QueryOrdered", order, "is out of order at", j, points[result[j-1]], points[result[j]])
+					t.Fail()
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package goors
+
+import "fmt"
+
+// Cursor marks where a paginated query stopped, see QueryPage. The zero Cursor is the start of a query.
+// A cursor only makes sense for the query and structure it was returned for.
+// It implements encoding.TextMarshaler and encoding.TextUnmarshaler so it can be handed to e.g. an HTTP client and back.
+type Cursor struct {
+	piece, offset int // the next point is at this offset into the y-interval of this piece of the query.
+	done          bool
+}
+
+// Done reports whether there are no more pages.
+func (self Cursor) Done() bool {
+	return self.done
+}
+
+func (self Cursor) MarshalText() ([]byte, error) {
+	if self.done {
+		return []byte("done"), nil
+	}
+	return []byte(fmt.Sprintf("%d.%d", self.piece, self.offset)), nil
+}
+
+func (self *Cursor) UnmarshalText(text []byte) error {
+	if string(text) == "done" {
+		*self = Cursor{done: true}
+		return nil
+	}
+	var result Cursor
+	_, err := fmt.Sscanf(string(text), "%d.%d", &result.piece, &result.offset)
+	if marshaled, _ := result.MarshalText(); err != nil || string(marshaled) != string(text) || result.piece < 0 || result.offset < 0 {
+		return ErrInvalidCursor
+	}
+	*self = result
+	return nil
+}
+
+// Reports at most limit of the points Query would report, starting where cursor points, and returns a cursor for the next page.
+// Pages do not overlap, and together they are the result of Query (in some order).
+// The returned cursor is done as soon as the last point has been reported, and a limit less than 1 also ends the pagination.
+// The query is decomposed again for every page, which takes O(log n) time, but the earlier pages are not enumerated again.
+func (self *RangeSearchAdvancedOf[T]) QueryPage(bottomLeft, topRight PointOf[T], limit int, cursor Cursor) ([]int, Cursor) {
+	if self.index == nil {
+		// not built yet.
+		return []int{}, Cursor{done: true}
+	}
+	x0, x1, y0, y1 := self.rankSpace.ReduceRect(MakeRectOf(bottomLeft, topRight))
+	return self.index.QueryRanksPage(x0, x1, y0, y1, limit, cursor)
+}
+
+// Same as QueryPage, for a query in rank space (see QueryRanks).
+func (self *RankSpaceIndex) QueryRanksPage(x0, x1, y0, y1, limit int, cursor Cursor) ([]int, Cursor) {
+	if cursor.done || limit <= 0 {
+		return []int{}, Cursor{done: true}
+	}
+	d := self.decompose(x0, x1, y0, y1, self.descendToLca)
+	count := self.count(d)
+	if count == 0 {
+		return []int{}, Cursor{done: true}
+	}
+	return self.reportPage(d, min(limit, count), cursor)
+}
+
+// Reports the next limit points of the query decomposed into d, starting at cursor. See QueryPage.
+func (self *RankSpaceIndex) reportPage(d decomposition, limit int, cursor Cursor) ([]int, Cursor) {
+	result := make([]int, 0, limit)
+	for cursor.piece < len(d.pieces) && len(result) < limit {
+		p := d.pieces[cursor.piece]
+		start := min(p.yLeft+cursor.offset, p.yRight)
+		if !isLeaf(p.node, self) {
+			end := min(p.yRight, start+limit-len(result))
+			result = append(result, self.ballInheritance[p.slot][start:end]...)
+			cursor.offset = end - p.yLeft
+		} else {
+			bucket := self.bucket(p.node)
+			for start < p.yRight && len(result) < limit {
+				point := bucket[start]
+				if !p.partial || point.x >= d.x0 && point.x < d.x1 {
+					result = append(result, point.i)
+				}
+				start++
+			}
+			cursor.offset = start - p.yLeft
+		}
+		if p.yLeft+cursor.offset >= p.yRight {
+			cursor.piece, cursor.offset = cursor.piece+1, 0
+		}
+	}
+	// a partial piece may have no points in the x-range left, so move past those to be done right after the last point.
+	for cursor.piece < len(d.pieces) && !self.pointsLeft(d, cursor) {
+		cursor.piece, cursor.offset = cursor.piece+1, 0
+	}
+	cursor.done = cursor.piece >= len(d.pieces)
+	return result, cursor
+}
+
+// Checks if the piece of d that cursor points into has any points of the query at or after the cursor.
+func (self *RankSpaceIndex) pointsLeft(d decomposition, cursor Cursor) bool {
+	p := d.pieces[cursor.piece]
+	start := min(p.yLeft+cursor.offset, p.yRight)
+	if !p.partial {
+		return start < p.yRight
+	}
+	for _, point := range self.bucket(p.node)[start:p.yRight] {
+		if point.x >= d.x0 && point.x < d.x1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package goors
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestQueryPage(t *testing.T) {
+	size := 3000
+	points := make([]Point, size)
+	rand.Seed(41)
+	for i := 0; i < size; i++ {
+		points[i] = Point{rand.Float64(), rand.Float64()}
+	}
+	dsAdvanced := NewRangeSearchAdvanced(points)
+	dsAdvanced.SetBucketSize(8)
+	dsAdvanced.Build()
+
+	for i := 0; i < 200; i++ {
+		x1, x2, y1, y2 := rand.Float64(), rand.Float64(), rand.Float64(), rand.Float64()
+		bottomLeft := Point{math.Min(x1, x2), math.Min(y1, y2)}
+		topRight := Point{math.Max(x1, x2), math.Max(y1, y2)}
+		if i%4 == 0 {
+			// a rectangle too thin to hold any point, but reaching into boundary leaves.
+			topRight = Point{bottomLeft.x + 1e-12, topRight.y}
+		}
+		expected := dsAdvanced.Query(bottomLeft, topRight)
+		limit := 1 + rand.Intn(100)
+		if i%8 == 1 {
+			// pages ending exactly on the last point.
+			limit = max(len(expected), 1)
+		}
+
+		all := []int{}
+		cursor := Cursor{}
+		pages := 0
+		for ; !cursor.Done(); pages++ {
+			if pages > len(expected)+1 {
+				fmt.Println("QueryPage does not terminate")
+				t.Fail()
+				return
+			}
+			// pass the cursor through its text form, as a client would.
+			text, _ := cursor.MarshalText()
+			var next Cursor
+			if err := next.UnmarshalText(text); err != nil || next != cursor {
+				fmt.Println("cursor", cursor, "did not survive marshaling:", string(text), err)
+				t.Fail()
+			}
+			var page []int
+			page, cursor = dsAdvanced.QueryPage(bottomLeft, topRight, limit, next)
+			if len(page) > limit {
+				fmt.Println("page of", len(page), "points, but the limit is", limit)
+				t.Fail()
+			}
+			all = append(all, page...)
+		}
+		if !sameIndices(expected, all) {
+			fmt.Println("the pages reported", len(all), "points, expected", len(expected))
+			t.Fail()
+		}
+		// the cursor must be done right after the last point, and an empty result is a single empty page.
+		if expectedPages := max((len(expected)+limit-1)/limit, 1); pages != expectedPages {
+			fmt.Println(len(expected), "points with limit", limit, "took", pages, "pages, expected", expectedPages)
+			t.Fail()
+		}
+	}
+
+	// an empty result on few points, and a limit of 0, both end the pagination at once.
+	ds := NewRangeSearchAdvanced([]Point{{0, 0}, {1, 1}, {2, 2}})
+	ds.Build()
+	if page, cursor := ds.QueryPage(Point{0, 1}, Point{0, 1}, 10, Cursor{}); len(page) != 0 || !cursor.Done() {
+		fmt.Println("QueryPage of an empty rectangle returned", page, cursor)
+		t.Fail()
+	}
+	if page, cursor := ds.QueryPage(Point{0, 0}, Point{2, 2}, 0, Cursor{}); len(page) != 0 || !cursor.Done() {
+		fmt.Println("QueryPage with limit 0 returned", page, cursor)
+		t.Fail()
+	}
+
+	var cursor Cursor
+	for _, text := range []string{"", "1", "1.2.3", "-1.0", "1.2x", "x"} {
+		if cursor.UnmarshalText([]byte(text)) != ErrInvalidCursor {
+			fmt.Println("accepted the invalid cursor", text)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package goors
+
+import (
+	"slices"
+	"sync"
+)
+
+// Below this many elements it is not worth spawning goroutines; a plain sort is faster.
+const parallelSortCutoff = 1 << 14
+
+// Sorts data according to compare using up to workers goroutines.
+// The slice is cut into one chunk per worker, the chunks are sorted concurrently,
+// and then merged pairwise (also concurrently) until a single sorted run is left.
+func parallelSort[E any](data []E, compare func(a, b E) int, workers int) {
+	if workers <= 1 || len(data) < parallelSortCutoff {
+		slices.SortFunc(data, compare)
+		return
+	}
+
+	chunkSize := (len(data) + workers - 1) / workers
+	runs := make([]int, 0, workers+1) // boundaries of the sorted runs.
+	for start := 0; start < len(data); start += chunkSize {
+		runs = append(runs, start)
+	}
+	runs = append(runs, len(data))
+
+	var wg sync.WaitGroup
+	for r := 0; r+1 < len(runs); r++ {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			slices.SortFunc(data[lo:hi], compare)
+		}(runs[r], runs[r+1])
+	}
+	wg.Wait()
+
+	sorted := mergeSortedRuns(data, runs, compare, workers)
+	if &sorted[0] != &data[0] {
+		copy(data, sorted)
+	}
+}
+
+// Merges the two sorted slices left and right into dst, which must have room for both.
+// Ties are taken from left first, so the merge is stable.
+func mergeRuns[E any](dst, left, right []E, compare func(a, b E) int) {
+	i, j, k := 0, 0, 0
+	for i < len(left) && j < len(right) {
+		if compare(right[j], left[i]) < 0 {
+			dst[k] = right[j]
+			j++
+		} else {
+			dst[k] = left[i]
+			i++
+		}
+		k++
+	}
+	k += copy(dst[k:], left[i:])
+	copy(dst[k:], right[j:])
+}
+
+// Merges the consecutive sorted runs of data, run r being data[runs[r]:runs[r+1]], pairwise until a single run is left.
+// If workers > 1 the merges of each round are done concurrently.
+// Returns the merged result, which is either data or a buffer of the same length.
+func mergeSortedRuns[E any](data []E, runs []int, compare func(a, b E) int, workers int) []E {
+	src := data
+	var dst []E
+	var wg sync.WaitGroup
+	for len(runs) > 2 {
+		if dst == nil {
+			dst = make([]E, len(data))
+		}
+		merged := make([]int, 0, len(runs)/2+2)
+		for r := 0; r+1 < len(runs); r += 2 {
+			lo := runs[r]
+			merged = append(merged, lo)
+			if r+2 >= len(runs) {
+				// odd run out, just carry it over.
+				copy(dst[lo:], src[lo:])
+				break
+			}
+			mid, hi := runs[r+1], runs[r+2]
+			if workers <= 1 {
+				mergeRuns(dst[lo:hi], src[lo:mid], src[mid:hi], compare)
+				continue
+			}
+			wg.Add(1)
+			go func(lo, mid, hi int) {
+				defer wg.Done()
+				mergeRuns(dst[lo:hi], src[lo:mid], src[mid:hi], compare)
+			}(lo, mid, hi)
+		}
+		merged = append(merged, len(data))
+		wg.Wait()
+		runs = merged
+		src, dst = dst, src
+	}
+	return src
+}
+
+// Runs work(lo, hi) on consecutive chunks of [0, n) using up to workers goroutines.
+func parallelFor(n, workers int, work func(lo, hi int)) {
+	if workers <= 1 || n < parallelSortCutoff {
+		work(0, n)
+		return
+	}
+	chunkSize := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += chunkSize {
+		hi := min(lo+chunkSize, n)
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			work(lo, hi)
+		}(lo, hi)
+	}
+	wg.Wait()
+}
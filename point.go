@@ -1,9 +1,31 @@
 package goors
 
-type Point struct {
-	x, y float64
+// PointOf is a point whose coordinates are of type T.
+// The structures only need to be able to compare coordinates, see NewRangeSearchAdvancedOf and NewRangeSearchAdvancedFunc.
+type PointOf[T any] struct {
+	x, y T
 }
 
+func MakePointOf[T any](x, y T) PointOf[T] {
+	return PointOf[T]{x, y}
+}
+
+func (self PointOf[T]) X() T {
+	return self.x
+}
+
+func (self PointOf[T]) Y() T {
+	return self.y
+}
+
+type Point = PointOf[float64]
+
 func MakePoint(x, y float64) Point {
 	return Point{x, y}
 }
+
+// Determines if point is contained in the closed rectangle defined by bottomLeft, topRight, when coordinates are ordered by compare.
+func isContained[T any](compare func(a, b T) int, bottomLeft, topRight, point PointOf[T]) bool {
+	return compare(point.x, bottomLeft.x) >= 0 && compare(point.x, topRight.x) <= 0 &&
+		compare(point.y, bottomLeft.y) >= 0 && compare(point.y, topRight.y) <= 0
+}
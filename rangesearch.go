@@ -1,6 +1,12 @@
 package goors
 
-type RangeSearch interface {
-	Query(bottomLeft, topRight Point) []int
+// RangeSearchOf is implemented by the range searching structures in this package.
+// Build must be called once before querying. Once it has returned, Query may be called concurrently from multiple goroutines.
+type RangeSearchOf[T any] interface {
+	Query(bottomLeft, topRight PointOf[T]) []int
+	// Same as Query, but reports at most limit points. truncated is true if there were more.
+	QueryLimit(bottomLeft, topRight PointOf[T], limit int) (indices []int, truncated bool)
 	Build()
 }
+
+type RangeSearch = RangeSearchOf[float64]
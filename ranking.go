@@ -0,0 +1,102 @@
+package goors
+
+import (
+	"github.com/jasn/gorasp"
+	"math/bits"
+)
+
+// Ranker is what the structures need from the rank-select structure of a bit array:
+// RankOfIndex returns the number of ones among the bits strictly before index, for 0 <= index <= len(bitArray).
+type Ranker interface {
+	RankOfIndex(index int) int
+}
+
+// RankerFactory creates the Ranker of a bit array, given as a slice of 0s and 1s.
+// The bit array is dropped once all the Rankers are built, so a Ranker must keep its own (preferably packed) copy of what it needs.
+type RankerFactory func(bitArray []int) Ranker
+
+// RankSelectFactory creates the rank structures of all nodes, unless a structure has its own (see SetRankSelectFactory).
+// It is read once at the start of each Build, so it should be set before building and not while a build is running.
+var RankSelectFactory RankerFactory = NewGoraspRankSelectFast
+
+// Adapts any of gorasp's rank-select structures to a Ranker.
+func FromGorasp(rankSelect gorasp.RankSelect) Ranker {
+	return goraspRanker{rankSelect}
+}
+
+type goraspRanker struct {
+	rankSelect gorasp.RankSelect
+}
+
+func (self goraspRanker) RankOfIndex(index int) int {
+	return int(self.rankSelect.RankOfIndex(index))
+}
+
+// Factory for gorasp's fast rank-select structure. This is the default.
+func NewGoraspRankSelectFast(bitArray []int) Ranker {
+	return FromGorasp(gorasp.NewRankSelectFast(bitArray))
+}
+
+// The layout of rankPoppy: a block of 2048 bits is split into four sub-blocks of 512 bits (8 words).
+const (
+	poppyBlockBits    = 2048
+	poppySubBlockBits = 512
+)
+
+// rankPoppy is a compact rank structure along the lines of "poppy" (Zhou, Andersen and Kaminsky, 2013).
+// The bits are packed into words, and for every block of 2048 bits a single word stores the number of ones before the block
+// (upper 32 bits) and the number of ones in each of the first three of its sub-blocks (10 bits each).
+// That is 64 bits of overhead per 2048 bits, about 3%, and a query popcounts at most 8 words.
+type rankPoppy struct {
+	words  []uint64
+	blocks []uint64
+}
+
+// Factory for the in-package compact rank structure, rankPoppy.
+// It uses far less memory than gorasp's fast structure, at the price of some popcounting per query.
+func NewRankPoppy(bitArray []int) Ranker {
+	words := make([]uint64, (len(bitArray)+63)/64)
+	for i, bit := range bitArray {
+		if bit != 0 {
+			words[i/64] |= 1 << uint(i%64)
+		}
+	}
+
+	// one extra block, so that RankOfIndex(len(bitArray)) has a block to look at.
+	blocks := make([]uint64, len(bitArray)/poppyBlockBits+1)
+	onesBefore := 0
+	for b := range blocks {
+		entry := uint64(onesBefore) << 32
+		for sub := 0; sub < 4; sub++ {
+			onesInSub := 0
+			for w := 0; w < poppySubBlockBits/64; w++ {
+				wordIndex := (b*poppyBlockBits+sub*poppySubBlockBits)/64 + w
+				if wordIndex < len(words) {
+					onesInSub += bits.OnesCount64(words[wordIndex])
+				}
+			}
+			if sub < 3 {
+				entry |= uint64(onesInSub) << uint(20-10*sub)
+			}
+			onesBefore += onesInSub
+		}
+		blocks[b] = entry
+	}
+	return &rankPoppy{words, blocks}
+}
+
+func (self *rankPoppy) RankOfIndex(index int) int {
+	entry := self.blocks[index/poppyBlockBits]
+	rank := int(entry >> 32)
+	sub := (index % poppyBlockBits) / poppySubBlockBits
+	for s := 0; s < sub; s++ {
+		rank += int(entry>>uint(20-10*s)) & 0x3ff
+	}
+	for w := (index - index%poppySubBlockBits) / 64; w < index/64; w++ {
+		rank += bits.OnesCount64(self.words[w])
+	}
+	if index%64 != 0 {
+		rank += bits.OnesCount64(self.words[index/64] & (1<<uint(index%64) - 1))
+	}
+	return rank
+}
@@ -0,0 +1,59 @@
+package goors
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestRankPoppy(t *testing.T) {
+	rand.Seed(23)
+	for _, size := range []int{0, 1, 63, 64, 65, 511, 512, 2047, 2048, 2049, 4096, 10000} {
+		for _, density := range []float64{0.0, 0.1, 0.5, 1.0} {
+			bitArray := make([]int, size)
+			for i := range bitArray {
+				if rand.Float64() < density {
+					bitArray[i] = 1
+				}
+			}
+			ranker := NewRankPoppy(bitArray)
+			ones := 0
+			for i := 0; i <= size; i++ {
+				if ranker.RankOfIndex(i) != ones {
+					fmt.Println("size", size, "density", density, ": RankOfIndex(", i, ") =", ranker.RankOfIndex(i), "expected", ones)
+					t.Fail()
+					break
+				}
+				if i < size {
+					ones += bitArray[i]
+				}
+			}
+		}
+	}
+}
+
+func TestRankSelectFactory(t *testing.T) {
+	size := 20000
+	points := make([]Point, size)
+	rand.Seed(29)
+	for i := 0; i < size; i++ {
+		points[i] = Point{rand.Float64(), rand.Float64()}
+	}
+	dsPoppy := NewRangeSearchAdvanced(points)
+	dsPoppy.SetRankSelectFactory(NewRankPoppy)
+	dsPoppy.Build()
+	dsSimple := NewRangeSearchSimple(points)
+	dsSimple.Build()
+
+	for i := 0; i < 200; i++ {
+		x1, x2 := rand.Float64(), rand.Float64()
+		y1, y2 := rand.Float64(), rand.Float64()
+		bottomLeft := Point{math.Min(x1, x2), math.Min(y1, y2)}
+		topRight := Point{math.Max(x1, x2), math.Max(y1, y2)}
+		if !sameIndices(dsSimple.Query(bottomLeft, topRight), dsPoppy.Query(bottomLeft, topRight)) {
+			fmt.Println("wrong result for", bottomLeft, topRight)
+			t.Fail()
+		}
+	}
+}
@@ -1,5 +1,11 @@
 package goors
 
+import (
+	"cmp"
+	"slices"
+	"sort"
+)
+
 type pointRankPerm struct {
 	x, y, i int
 }
@@ -18,16 +24,183 @@ func (self byXRank) Less(i, j int) bool {
 	return self[i].x < self[j].x
 }
 
-type byYRank []pointRankPerm
+// Axis selects one of the two coordinates.
+type Axis int
 
-func (self byYRank) Len() int {
-	return len(self)
+const (
+	AxisX Axis = iota
+	AxisY
+)
+
+// RankSpaceOf is the reduction of a set of points to rank space, together with the mapping back.
+// Sorting the points on each axis, with ties broken by index, assigns every point a distinct rank 0..n-1 per axis.
+// Several points may share a coordinate, but never a rank: the points with coordinate v on an axis have
+// exactly the ranks [lo, hi[ returned by RankOf for v.
+//
+// A RankSpaceOf is not tied to any particular structure, so several indices over the same points can share one.
+// It is never modified after construction and is safe for concurrent use.
+type RankSpaceOf[T any] struct {
+	points     []PointOf[T]
+	compare    func(a, b T) int
+	xCoords    []T // xCoords[r] is the x-coordinate of the point with x-rank r.
+	yCoords    []T
+	rankPoints []RankPoint // the points in rank space, in the same order as points.
 }
 
-func (self byYRank) Swap(i, j int) {
-	self[i], self[j] = self[j], self[i]
+type RankSpace = RankSpaceOf[float64]
+
+// Returns the number of points.
+func (self *RankSpaceOf[T]) Len() int {
+	return len(self.rankPoints)
+}
+
+// Returns the points in rank space, in the order they were given to the constructor. The slice must not be modified.
+func (self *RankSpaceOf[T]) RankPoints() []RankPoint {
+	return self.rankPoints
+}
+
+// Returns the ranks on the given axis of the points whose coordinate is v, as the half-open interval [lo, hi[.
+// If no point has coordinate v, lo == hi is the number of points with a smaller coordinate.
+func (self *RankSpaceOf[T]) RankOf(axis Axis, v T) (lo, hi int) {
+	coords := self.coords(axis)
+	return searchCoordinates(coords, v, false, self.compare), searchCoordinates(coords, v, true, self.compare)
+}
+
+// Returns the coordinate on the given axis of the point with the given rank.
+func (self *RankSpaceOf[T]) CoordOf(axis Axis, rank int) T {
+	return self.coords(axis)[rank]
+}
+
+// Reduces the closed rectangle to the half-open rank intervals [x0, x1[ and [y0, y1[ containing exactly the ranks
+// of the points inside it, i.e. the arguments for RankSpaceIndex.QueryRanks.
+func (self *RankSpaceOf[T]) ReduceRect(rect RectOf[T]) (x0, x1, y0, y1 int) {
+	x0 = searchCoordinates(self.xCoords, rect.bottomLeft.x, false, self.compare)
+	x1 = searchCoordinates(self.xCoords, rect.topRight.x, true, self.compare)
+	y0 = searchCoordinates(self.yCoords, rect.bottomLeft.y, false, self.compare)
+	y1 = searchCoordinates(self.yCoords, rect.topRight.y, true, self.compare)
+	return x0, x1, y0, y1
+}
+
+// The sorted coordinates of the given axis.
+func (self *RankSpaceOf[T]) coords(axis Axis) []T {
+	if axis == AxisX {
+		return self.xCoords
+	}
+	return self.yCoords
+}
+
+// A query rectangle reduced to rank space, as half-open intervals [x0, x1[ and [y0, y1[.
+type rankRect struct {
+	x0, x1, y0, y1 int
+}
+
+// Batch version of ReduceRect.
+func (self *RankSpaceOf[T]) reduceRects(rects []RectOf[T]) []rankRect {
+	n := len(rects)
+	bottomLeftXs := make([]T, n)
+	bottomLeftYs := make([]T, n)
+	topRightXs := make([]T, n)
+	topRightYs := make([]T, n)
+	for i, rect := range rects {
+		bottomLeftXs[i] = rect.bottomLeft.x
+		bottomLeftYs[i] = rect.bottomLeft.y
+		topRightXs[i] = rect.topRight.x
+		topRightYs[i] = rect.topRight.y
+	}
+	x0s := searchCoordinatesBatch(self.xCoords, bottomLeftXs, false, self.compare)
+	y0s := searchCoordinatesBatch(self.yCoords, bottomLeftYs, false, self.compare)
+	x1s := searchCoordinatesBatch(self.xCoords, topRightXs, true, self.compare)
+	y1s := searchCoordinatesBatch(self.yCoords, topRightYs, true, self.compare)
+
+	result := make([]rankRect, n)
+	for i := range rects {
+		result[i] = rankRect{x0s[i], x1s[i], y0s[i], y1s[i]}
+	}
+	return result
+}
+
+// Finds the index of the first coordinate in the sorted slice coords that is >= key (or > key if upper is set).
+func searchCoordinates[T any](coords []T, key T, upper bool, compare func(a, b T) int) int {
+	return sort.Search(len(coords), func(i int) bool {
+		if upper {
+			return compare(coords[i], key) > 0
+		}
+		return compare(coords[i], key) >= 0
+	})
+}
+
+// Batch version of searchCoordinates: for every key finds the index of the first coordinate in the sorted slice coords
+// that is >= key (or > key if upper is set).
+// The keys are handled in increasing order, so each binary search only has to consider the coordinates
+// after the answer for the previous key. Equal keys are only searched for once.
+func searchCoordinatesBatch[T any](coords []T, keys []T, upper bool, compare func(a, b T) int) []int {
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int { return compare(keys[a], keys[b]) })
+
+	result := make([]int, len(keys))
+	from := 0
+	for j, i := range order {
+		if j > 0 && compare(keys[i], keys[order[j-1]]) == 0 {
+			result[i] = result[order[j-1]]
+			continue
+		}
+		from += searchCoordinates(coords[from:], keys[i], upper, compare)
+		result[i] = from
+	}
+	return result
+}
+
+// Reduces points to rank space by sorting them on each axis, the sorting is split among workers goroutines.
+func newRankSpace[T any](points []PointOf[T], compare func(a, b T) int, workers int) *RankSpaceOf[T] {
+	xOrder := sortedIndicesByCoordinate(points, func(p PointOf[T]) T { return p.x }, compare, workers)
+	yOrder := sortedIndicesByCoordinate(points, func(p PointOf[T]) T { return p.y }, compare, workers)
+
+	result := new(RankSpaceOf[T])
+	result.points = points
+	result.compare = compare
+	result.xCoords = make([]T, len(points))
+	result.yCoords = make([]T, len(points))
+	result.rankPoints = make([]RankPoint, len(points))
+	for rank, index := range xOrder {
+		result.xCoords[rank] = points[index].x
+		result.rankPoints[index].X = rank
+	}
+	for rank, index := range yOrder {
+		result.yCoords[rank] = points[index].y
+		result.rankPoints[index].Y = rank
+	}
+	return result
+}
+
+// Returns the indices of points sorted by the given coordinate, with ties broken by index.
+// The position of an index in the result is the rank of that point.
+func sortedIndicesByCoordinate[T any](points []PointOf[T], coordinate func(p PointOf[T]) T, compare func(a, b T) int, workers int) []int {
+	order := make([]int, len(points))
+	for i := range order {
+		order[i] = i
+	}
+	parallelSort(order, func(a, b int) int {
+		if c := compare(coordinate(points[a]), coordinate(points[b])); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	}, workers)
+	return order
+}
+
+func NewRankSpace(points []Point) *RankSpace {
+	return NewRankSpaceOf(points)
+}
+
+// Constructor for coordinates with a natural ordering, such as integers, floats and strings.
+func NewRankSpaceOf[T cmp.Ordered](points []PointOf[T]) *RankSpaceOf[T] {
+	return NewRankSpaceFunc(points, cmp.Compare[T])
 }
 
-func (self byYRank) Less(i, j int) bool {
-	return self[i].y < self[j].y
+// Constructor for coordinates ordered by compare, see NewRangeSearchAdvancedFunc.
+func NewRankSpaceFunc[T any](points []PointOf[T], compare func(a, b T) int) *RankSpaceOf[T] {
+	return newRankSpace(points, compare, 1)
 }
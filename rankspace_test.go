@@ -0,0 +1,60 @@
+package goors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRankSpace(t *testing.T) {
+	points := []Point{{3.0, 1.0}, {1.0, 1.0}, {3.0, 2.0}, {2.0, 1.0}, {3.0, 0.0}}
+	rankSpace := NewRankSpace(points)
+
+	// ties are broken by index, so the three points with x = 3 get x-ranks 2, 3, 4 in input order.
+	expected := []RankPoint{{2, 1}, {0, 2}, {3, 4}, {1, 3}, {4, 0}}
+	for i, p := range rankSpace.RankPoints() {
+		if p != expected[i] {
+			fmt.Println("point", i, "has ranks", p, "expected", expected[i])
+			t.Fail()
+		}
+	}
+
+	lo, hi := rankSpace.RankOf(AxisX, 3.0)
+	if lo != 2 || hi != 5 {
+		fmt.Println("RankOf(AxisX, 3) =", lo, hi, "expected 2 5")
+		t.Fail()
+	}
+	lo, hi = rankSpace.RankOf(AxisY, 1.5)
+	if lo != 4 || hi != 4 {
+		fmt.Println("RankOf(AxisY, 1.5) =", lo, hi, "expected 4 4")
+		t.Fail()
+	}
+	for rank, coord := range []float64{0.0, 1.0, 1.0, 1.0, 2.0} {
+		if rankSpace.CoordOf(AxisY, rank) != coord {
+			fmt.Println("CoordOf(AxisY,", rank, ") =", rankSpace.CoordOf(AxisY, rank), "expected", coord)
+			t.Fail()
+		}
+	}
+
+	x0, x1, y0, y1 := rankSpace.ReduceRect(MakeRect(Point{2.0, 1.0}, Point{3.0, 1.0}))
+	if x0 != 1 || x1 != 5 || y0 != 1 || y1 != 4 {
+		fmt.Println("ReduceRect gave", x0, x1, y0, y1, "expected 1 5 1 4")
+		t.Fail()
+	}
+}
+
+func TestSharedRankSpace(t *testing.T) {
+	points := []Point{{0.0, 0.0}, {5.0, 5.0}, {10.0, 10.0}, {15.0, 15.0}}
+	rankSpace := NewRankSpace(points)
+	first := NewRangeSearchAdvancedWithRankSpace(rankSpace)
+	first.Build()
+	second := NewRangeSearchAdvancedWithRankSpace(rankSpace)
+	second.BuildParallel(2)
+
+	for _, ds := range []*RangeSearchAdvancedOf[float64]{first, second} {
+		result := ds.Query(Point{4.0, 4.0}, Point{11.0, 11.0})
+		if !sameIndices([]int{1, 2}, result) {
+			fmt.Println("Expected [1 2], but received", result)
+			t.Fail()
+		}
+	}
+}
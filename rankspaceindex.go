@@ -0,0 +1,696 @@
+package goors
+
+import (
+	"cmp"
+	"math/bits"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// RankPoint is a point in rank space.
+type RankPoint = struct {
+	X, Y int
+}
+
+// RankSpaceIndex is the range searching structure on points that are already in rank space:
+// the X coordinates of the n points form a permutation of 0..n-1, and so do the Y coordinates.
+// This is where all the work happens, RangeSearchAdvancedOf only reduces its input to rank space and queries one of these.
+//
+// The same rules for concurrency as for RangeSearchAdvancedOf apply: build once, then query from any number of goroutines.
+type RankSpaceIndex struct {
+	points               []RankPoint
+	pointsRankSpace      []pointRankPerm
+	xTree                []int
+	xTreeHeight          int // number of nodes on root to leaf path including root and leaf.
+	firstLeaf            int // node number of the leftmost leaf, which is also the number of internal nodes.
+	bucketSize           int // number of points in each leaf, see SetBucketSize.
+	layout               TreeLayout
+	childSlots           []int   // slots of the children of the node in each slot, see setChildSlots.
+	bitArrays            [][]int // one int per bit, only kept until the rank-select structures are built.
+	rankSelectStructures []Ranker
+	rankSelectFactory    RankerFactory // nil means the package-level RankSelectFactory.
+	ballInheritance      [][]int
+	colors               []int      // color of each point, see SetColors.
+	colorTrees           [][]int    // by slot, see buildColorTrees.
+	attributes           []uint64   // attribute mask of each point, see SetAttributes.
+	attributeOrs         [][]uint64 // by slot, see buildAttributeTrees.
+	attributeAnds        [][]uint64
+}
+
+func getNextPowerOfTwo(n int) int {
+	if n <= 2 {
+		return n
+	}
+	n = n - 1
+	for i := uint(1); n > 0; i++ {
+		n = n >> 1
+		if n == 0 {
+			return 1 << i
+		}
+	}
+	return 1 << 30
+}
+
+// This is a neat trick to find the LCA of two nodes on the *same* level (that detail is important!)
+// It of course only works when we zero-index and use a heap-layout.
+func lowestCommonAncestor(left, right int) int {
+	xor := uint64((left + 1) ^ (right + 1))
+	zeros := bits.LeadingZeros64(xor)
+	shift := uint(64 - zeros)
+	return ((left + 1) >> shift) - 1
+}
+
+// currentIndex denotes a y-rank at the current node.
+// when descending we want to maintain an interval [l,r] such that all y-coordinates to be reported fall in that range.
+// this function is used when descending to the left.
+func descendLeft(currentIndex int, rankSelectStruct Ranker) int {
+	onesLeft := rankSelectStruct.RankOfIndex(currentIndex)
+	zeros := currentIndex - onesLeft
+	return zeros
+}
+
+// similar to descendLeft
+func descendRight(currentIndex int, rankSelectStruct Ranker) int {
+	return rankSelectStruct.RankOfIndex(currentIndex)
+}
+
+// in BFS order the last half of the nodes are leaves.
+// this function tests if a node represented by n is in fact a leaf.
+func isLeaf(n int, self *RankSpaceIndex) bool {
+	return n >= self.firstLeaf
+}
+
+// A canonical piece of a query: the points in the subtree of node (stored in slot) whose y-ranks at node are in [yLeft, yRight[.
+// If partial is set, node is a leaf on the boundary of the query's x-range, and only those of its points
+// with an x-rank inside the x-range of the query belong to the query.
+type piece struct {
+	node, slot, yLeft, yRight int
+	partial                   bool
+}
+
+// A query with x-ranks [x0, x1[ split into O(log n) disjoint pieces, ordered by x.
+// Only the (at most two) leaves at the ends of the x-range can be partial.
+type decomposition struct {
+	x0, x1 int
+	pieces []piece
+}
+
+// Appends the indices of the points of p, which is one of the pieces of d, to result.
+func (self *RankSpaceIndex) reportPiece(result []int, d decomposition, p piece) []int {
+	if !isLeaf(p.node, self) {
+		return append(result, self.ballInheritance[p.slot][p.yLeft:p.yRight]...)
+	}
+	// the bucket is sorted by y-rank, so [yLeft, yRight[ are positions in it.
+	for _, point := range self.bucket(p.node)[p.yLeft:p.yRight] {
+		if !p.partial || point.x >= d.x0 && point.x < d.x1 {
+			result = append(result, point.i)
+		}
+	}
+	return result
+}
+
+// The number of points in p, which is one of the pieces of d. O(1) unless p is partial.
+func (self *RankSpaceIndex) pieceSize(d decomposition, p piece) int {
+	if !p.partial {
+		return p.yRight - p.yLeft
+	}
+	count := 0
+	for _, point := range self.bucket(p.node)[p.yLeft:p.yRight] {
+		if point.x >= d.x0 && point.x < d.x1 {
+			count++
+		}
+	}
+	return count
+}
+
+// The bounding box in rank space of the points of the piece p, which must not be partial:
+// x-ranks [x0, x1[ are those below p.node (which may be more than those of its points), and y-ranks [y0, y1[ are exact.
+func (self *RankSpaceIndex) pieceBox(p piece) (x0, x1, y0, y1 int) {
+	x0, x1 = self.xRanksBelow(p.node)
+	if isLeaf(p.node, self) {
+		bucket := self.bucket(p.node)
+		return x0, x1, bucket[p.yLeft].y, bucket[p.yRight-1].y + 1
+	}
+	ball := self.ballInheritance[p.slot]
+	return x0, x1, self.points[ball[p.yLeft]].Y, self.points[ball[p.yRight-1]].Y + 1
+}
+
+// The number of points in the query decomposed into d.
+func (self *RankSpaceIndex) count(d decomposition) int {
+	count := 0
+	for _, p := range d.pieces {
+		count += self.pieceSize(d, p)
+	}
+	return count
+}
+
+// Reports the points of the query decomposed into d, of which there are count.
+func (self *RankSpaceIndex) report(d decomposition, count int) []int {
+	result := make([]int, 0, count)
+	for _, p := range d.pieces {
+		result = self.reportPiece(result, d, p)
+	}
+	return result
+}
+
+// After finding the lca, this function is called with node=lca's right child, stored in slot.
+// This function then keeps descending toward the node with key xRankMax, while
+// appending all subtrees that are strictly to the left to pieces.
+func (self *RankSpaceIndex) reportLeftHanging(node, slot, yLeft, yRight, xRankMax int, pieces []piece) []piece {
+	if yLeft >= yRight {
+		return pieces
+	}
+	if isLeaf(node, self) {
+		return append(pieces, piece{node, slot, yLeft, yRight, true})
+	}
+	rightChild := rightChild(node)
+	leftChild := leftChild(node)
+
+	keyOfMe := self.xTree[slot]
+	if keyOfMe == -1 {
+		return pieces
+	}
+	ranker := self.rankSelectStructures[slot]
+	if xRankMax > keyOfMe {
+		// report left childs everything.
+		yLeftTmp := descendLeft(yLeft, ranker)
+		yRightTmp := descendLeft(yRight, ranker)
+		if yLeftTmp < yRightTmp {
+			pieces = append(pieces, piece{leftChild, self.leftChildSlot(slot), yLeftTmp, yRightTmp, false})
+		}
+
+		// then descend right.
+		yLeftNew := descendRight(yLeft, ranker)
+		yRightNew := descendRight(yRight, ranker)
+		return self.reportLeftHanging(rightChild, self.rightChildSlot(slot), yLeftNew, yRightNew, xRankMax, pieces)
+	} else {
+		// descendLeft and do the same again.
+		yLeftNew := descendLeft(yLeft, ranker)
+		yRightNew := descendLeft(yRight, ranker)
+		return self.reportLeftHanging(leftChild, self.leftChildSlot(slot), yLeftNew, yRightNew, xRankMax, pieces)
+	}
+}
+
+// symmetric to reportLeftHanging
+func (self *RankSpaceIndex) reportRightHanging(node, slot, yLeft, yRight, xRankMin int, pieces []piece) []piece {
+	if yLeft >= yRight {
+		return pieces
+	}
+	if isLeaf(node, self) {
+		return append(pieces, piece{node, slot, yLeft, yRight, true})
+	}
+
+	rightChild := rightChild(node)
+	leftChild := leftChild(node)
+
+	keyOfMe := self.xTree[slot]
+	if keyOfMe == -1 {
+		return pieces
+	}
+	ranker := self.rankSelectStructures[slot]
+
+	if xRankMin <= keyOfMe {
+		// descend left first, such that the pieces stay ordered by x.
+		yLeftNew := descendLeft(yLeft, ranker)
+		yRightNew := descendLeft(yRight, ranker)
+		pieces = self.reportRightHanging(leftChild, self.leftChildSlot(slot), yLeftNew, yRightNew, xRankMin, pieces)
+
+		// then report right childs everything.
+		yLeftTmp := descendRight(yLeft, ranker)
+		yRightTmp := descendRight(yRight, ranker)
+		if yLeftTmp < yRightTmp {
+			pieces = append(pieces, piece{rightChild, self.rightChildSlot(slot), yLeftTmp, yRightTmp, false})
+		}
+		return pieces
+	} else {
+		// descendRight and do the same again.
+		yLeftNew := descendRight(yLeft, ranker)
+		yRightNew := descendRight(yRight, ranker)
+		return self.reportRightHanging(rightChild, self.rightChildSlot(slot), yLeftNew, yRightNew, xRankMin, pieces)
+	}
+}
+
+// This function computes the y-rank-interval at a node lca, given that at the root the interval is [yLeft, yRight[ (half open).
+func (self *RankSpaceIndex) descendToLca(lca, yLeft, yRight int) (int, int) {
+	searchKey := self.key(lca)
+	yLeftNew := yLeft
+	yRightNew := yRight
+	node, slot := 0, 0 // the root is in slot 0 in every layout.
+	for node != lca && node < self.firstLeaf {
+		ranker := self.rankSelectStructures[slot]
+		if searchKey <= self.xTree[slot] {
+			yLeftNew = descendLeft(yLeftNew, ranker)
+			yRightNew = descendLeft(yRightNew, ranker)
+			node, slot = leftChild(node), self.leftChildSlot(slot)
+		} else {
+			yLeftNew = descendRight(yLeftNew, ranker)
+			yRightNew = descendRight(yRightNew, ranker)
+			node, slot = rightChild(node), self.rightChildSlot(slot)
+		}
+	}
+	return yLeftNew, yRightNew
+}
+
+// convenience function, called with node=lca (stored in slot) when processing a query.
+// Initiates the search towards the lower x-coordinate in the query range.
+func (self *RankSpaceIndex) branchLeftReport(node, slot, yLeft, yRight, xMinRank int, pieces []piece) []piece {
+	ranker := self.rankSelectStructures[slot]
+	yLeftNew := descendLeft(yLeft, ranker)
+	yRightNew := descendLeft(yRight, ranker)
+	return self.reportRightHanging(leftChild(node), self.leftChildSlot(slot), yLeftNew, yRightNew, xMinRank, pieces)
+}
+
+// symmetric to branchLeftReport
+func (self *RankSpaceIndex) branchRightReport(node, slot, yLeft, yRight, xMaxRank int, pieces []piece) []piece {
+	ranker := self.rankSelectStructures[slot]
+	yLeftNew := descendRight(yLeft, ranker)
+	yRightNew := descendRight(yRight, ranker)
+	return self.reportLeftHanging(rightChild(node), self.rightChildSlot(slot), yLeftNew, yRightNew, xMaxRank, pieces)
+}
+
+// Checks if both x-coordinates ended up in the same leaf.
+// can happen either at the very end meaning the rightLeafIndex is one past the end of the array
+func bothXCoordinatesInSameLeaf(leafIndexLeft, leafIndexRight, onePastLastLeafIndex int) bool {
+	caseOne := leafIndexLeft == leafIndexRight
+	caseTwo := leafIndexRight == onePastLastLeafIndex && leafIndexLeft == onePastLastLeafIndex-1
+	return caseOne || caseTwo
+}
+
+// QueryRanks reports the points with x in [x0, x1[ and y in [y0, y1[ (half-open intervals).
+// The result is a slice of indices into the points given to the constructor.
+func (self *RankSpaceIndex) QueryRanks(x0, x1, y0, y1 int) []int {
+	return self.queryRanks(x0, x1, y0, y1, self.descendToLca)
+}
+
+// CountRanks returns the number of points QueryRanks would report, in O(log n) time.
+func (self *RankSpaceIndex) CountRanks(x0, x1, y0, y1 int) int {
+	return self.count(self.decompose(x0, x1, y0, y1, self.descendToLca))
+}
+
+// Same as QueryRanks, but reports at most limit points, and whether there were more.
+func (self *RankSpaceIndex) QueryRanksLimit(x0, x1, y0, y1, limit int) ([]int, bool) {
+	limit = max(limit, 0)
+	d := self.decompose(x0, x1, y0, y1, self.descendToLca)
+	count := self.count(d)
+	if count <= limit {
+		return self.report(d, count), false
+	}
+	result, _ := self.reportPage(d, limit, Cursor{})
+	return result, true
+}
+
+// The implementation of QueryRanks. descend is used to compute the y-rank-interval at the lca, see descendToLca.
+func (self *RankSpaceIndex) queryRanks(x0, x1, y0, y1 int, descend func(lca, yLeft, yRight int) (int, int)) []int {
+	d := self.decompose(x0, x1, y0, y1, descend)
+	return self.report(d, self.count(d))
+}
+
+// Splits the query with x in [x0, x1[ and y in [y0, y1[ into pieces, see decomposition.
+// descend is used to compute the y-rank-interval at the lca, see descendToLca.
+func (self *RankSpaceIndex) decompose(x0, x1, y0, y1 int, descend func(lca, yLeft, yRight int) (int, int)) decomposition {
+	x0, y0 = max(x0, 0), max(y0, 0)
+	x1, y1 = min(x1, len(self.points)), min(y1, len(self.points))
+	d := decomposition{x0: x0, x1: x1}
+	if self.xTree == nil || x0 >= x1 || y0 >= y1 {
+		// not built yet, or an empty query (which covers being built on no points).
+		return d
+	}
+	leafIndexLeft := self.leafOf(x0)
+	leafIndexRight := self.leafOf(x1)
+
+	onePastLastLeafIndex := self.firstLeaf + self.numberOfBuckets()
+	// special case, the whole query is inside a single bucket.
+	if bothXCoordinatesInSameLeaf(leafIndexLeft, leafIndexRight, onePastLastLeafIndex) {
+		yLeft, yRight := descend(leafIndexLeft, y0, y1)
+		if yLeft < yRight {
+			d.pieces = []piece{{leafIndexLeft, self.slot(leafIndexLeft), yLeft, yRight, true}}
+		}
+		return d
+	}
+
+	// general case
+	var lca int = 0
+	if leafIndexRight <= 2*self.firstLeaf {
+		lca = lowestCommonAncestor(leafIndexLeft, leafIndexRight)
+	} else {
+		lca = lowestCommonAncestor(leafIndexLeft, leafIndexRight-1)
+	}
+	yLeft, yRight := descend(lca, y0, y1)
+
+	lcaSlot := self.slot(lca)
+	d.pieces = make([]piece, 0, 2*self.xTreeHeight)
+	d.pieces = self.branchLeftReport(lca, lcaSlot, yLeft, yRight, x0, d.pieces)
+	d.pieces = self.branchRightReport(lca, lcaSlot, yLeft, yRight, x1, d.pieces)
+	return d
+}
+
+// Helper function for building the bit arrays and ball-inheritance structure.
+// The tree is built one level at a time. The points of a level are kept in one slice, sorted by node and then by y-rank,
+// so every node on the level owns a contiguous range of it. Each node stably partitions its range into the next level's slice,
+// which then has the same property. The bit arrays and ball-inheritance are already allocated in slot order, see initializeRankSelectBallInheritance.
+func (self *RankSpaceIndex) buildRankSelectAndBallInheritance() {
+	self.initializeRankSelectBallInheritance()
+
+	current := sortByYRank(self.pointsRankSpace)
+	next := make([]pointRankPerm, len(current))
+	// number of points in the subtree of each node on the current level, from left to right.
+	sizes := []int{len(current)}
+	numberOfInternalNodes := self.firstLeaf
+	for levelStart := 0; levelStart < numberOfInternalNodes; levelStart = 2*levelStart + 1 {
+		childSizes := make([]int, 0, 2*len(sizes))
+		offset := 0
+		for i, size := range sizes {
+			node := levelStart + i
+			zeros := 0
+			if size > 0 {
+				end := offset + size
+				zeros = self.partitionNode(node, current[offset:end], next[offset:end])
+			}
+			childSizes = append(childSizes, zeros, size-zeros)
+			offset += size
+		}
+		sizes = childSizes
+		current, next = next, current
+	}
+	self.buildRankers(1)
+}
+
+// Returns a copy of points sorted by y-rank. Since ranks are less than len(points) this is a counting sort, and it is stable.
+func sortByYRank(points []pointRankPerm) []pointRankPerm {
+	counts := make([]int, len(points)+1)
+	for _, p := range points {
+		counts[p.y+1]++
+	}
+	for i := 1; i < len(counts); i++ {
+		counts[i] += counts[i-1]
+	}
+	result := make([]pointRankPerm, len(points))
+	for _, p := range points {
+		result[counts[p.y]] = p
+		counts[p.y]++
+	}
+	return result
+}
+
+// Below this many points a subtree is built by the goroutine that reached it, rather than a new one.
+const parallelSubtreeCutoff = 1 << 12
+
+// Parallel counterpart of buildRankSelectAndBallInheritance.
+// The points are sorted by y-rank once, and then each node stably partitions its points
+// (already in y-order) by its key into the part going left and the part going right.
+// Since the two halves are disjoint, the subtrees can be built in their own goroutines.
+func (self *RankSpaceIndex) buildRankSelectAndBallInheritanceParallel(workers int) {
+	self.initializeRankSelectBallInheritance()
+
+	pointsByY := make([]pointRankPerm, len(self.pointsRankSpace))
+	copy(pointsByY, self.pointsRankSpace)
+	parallelSort(pointsByY, func(a, b pointRankPerm) int { return cmp.Compare(a.y, b.y) }, workers)
+	scratch := make([]pointRankPerm, len(pointsByY))
+
+	// a goroutine may only be spawned by taking a token, which bounds the parallelism.
+	tokens := make(chan struct{}, workers-1)
+	var wg sync.WaitGroup
+	self.buildSubtree(0, pointsByY, scratch, tokens, &wg)
+	wg.Wait()
+	self.buildRankers(workers)
+}
+
+// Builds the bit array and ball-inheritance of node and everything below it.
+// points are the points in the subtree of node in increasing y-rank, and scratch is a buffer of the same length
+// which the partitioned points are written to (the roles of the two are swapped for the children).
+func (self *RankSpaceIndex) buildSubtree(node int, points, scratch []pointRankPerm, tokens chan struct{}, wg *sync.WaitGroup) {
+	if isLeaf(node, self) || len(points) == 0 {
+		return
+	}
+	zeros := self.partitionNode(node, points, scratch)
+
+	leftChild := leftChild(node)
+	rightChild := rightChild(node)
+	if zeros >= parallelSubtreeCutoff {
+		select {
+		case tokens <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				self.buildSubtree(leftChild, scratch[:zeros], points[:zeros], tokens, wg)
+				<-tokens
+			}()
+			self.buildSubtree(rightChild, scratch[zeros:], points[zeros:], tokens, wg)
+			return
+		default:
+		}
+	}
+	self.buildSubtree(leftChild, scratch[:zeros], points[:zeros], tokens, wg)
+	self.buildSubtree(rightChild, scratch[zeros:], points[zeros:], tokens, wg)
+}
+
+// Fills in the bit array and ball-inheritance of node, given the points of its subtree in y-order.
+// The points are stably partitioned into dst: those going left first, followed by those going right.
+// Returns the number of points going left (the number of zeros in the bit array).
+func (self *RankSpaceIndex) partitionNode(node int, points, dst []pointRankPerm) int {
+	slot := self.slot(node)
+	key := self.xTree[slot]
+	bitArray := self.bitArrays[slot]
+	ballInheritance := self.ballInheritance[slot]
+	zeros := 0
+	for i, p := range points {
+		ballInheritance[i] = p.i
+		if p.x <= key {
+			bitArray[i] = 0
+			zeros++
+		} else {
+			bitArray[i] = 1
+		}
+	}
+
+	left, right := 0, zeros
+	for i, p := range points {
+		if bitArray[i] == 0 {
+			dst[left] = p
+			left++
+		} else {
+			dst[right] = p
+			right++
+		}
+	}
+	return zeros
+}
+
+// helper function to initialize all the important arrays.
+// They are indexed by slot, and all internal nodes come before the leaves, so there is no need for slots for those.
+// The bit arrays and ball-inheritance of all nodes are carved out of one allocation each, in slot order,
+// such that nodes stored close together in the tree also have their data close together.
+func (self *RankSpaceIndex) initializeRankSelectBallInheritance() {
+	numberOfSlots := self.firstLeaf
+	self.bitArrays = make([][]int, numberOfSlots)
+	self.rankSelectStructures = make([]Ranker, numberOfSlots)
+	self.ballInheritance = make([][]int, numberOfSlots)
+
+	// the x-ranks are a permutation, so the size of a node is the number of x-ranks below it.
+	sizes := make([]int, numberOfSlots)
+	total := 0
+	for node := 0; node < self.firstLeaf; node++ {
+		lo, hi := self.xRanksBelow(node)
+		size := max(hi-lo, 0)
+		sizes[self.slot(node)] = size
+		total += size
+	}
+	bits := make([]int, total)
+	balls := make([]int, total)
+	offset := 0
+	for slot, size := range sizes {
+		end := offset + size
+		self.bitArrays[slot] = bits[offset:end:end]
+		self.ballInheritance[slot] = balls[offset:end:end]
+		offset = end
+	}
+}
+
+// Creates the rank-select structures of the bit arrays, in slot order, using up to workers goroutines.
+// Nodes without points get no rank-select structure. Afterwards the bit arrays are dropped, since the rank-select
+// structures hold the same bits in far less space.
+func (self *RankSpaceIndex) buildRankers(workers int) {
+	parallelFor(len(self.bitArrays), workers, func(lo, hi int) {
+		for slot := lo; slot < hi; slot++ {
+			if len(self.bitArrays[slot]) > 0 {
+				self.rankSelectStructures[slot] = self.rankSelectFactory(self.bitArrays[slot])
+			}
+		}
+	})
+	self.bitArrays = nil
+}
+
+// Set the keys of the leaves appropriately.
+// Leaf i holds the bucketSize points following the first i*bucketSize in x-order, and its key is the largest x-rank among them.
+func setLeavesOfXTree(xTree []int, pointsRankSpace []pointRankPerm, bucketSize int) []int {
+	arrayLength := len(xTree)
+	leafsStartAt := arrayLength / 2
+	leafsEndAt := leafsStartAt
+	for start := 0; start < len(pointsRankSpace); start += bucketSize {
+		end := min(start+bucketSize, len(pointsRankSpace))
+		xTree[leafsEndAt] = pointsRankSpace[end-1].x
+		leafsEndAt++
+	}
+
+	noDataValue := -1
+	for i := leafsEndAt; i < len(xTree); i++ {
+		xTree[i] = noDataValue
+	}
+	return xTree
+}
+
+// Set the keys of internal nodes appropriately.
+// We define the key of an internal node to be the largest key in its left subtree.
+func setInternalNodesOfXTree(xTree []int) []int {
+	var maxSubTree func(n int) int
+	maxSubTree = func(n int) int {
+		rightChild := 2*n + 2
+		if rightChild > len(xTree) {
+			return xTree[n]
+		}
+		return maxSubTree(rightChild)
+	}
+
+	maxLeftSubTree := func(n int) int {
+		leftChild := 2*n + 1
+		if leftChild > len(xTree) {
+			return maxSubTree(n)
+		}
+		return maxSubTree(leftChild)
+	}
+
+	arrayLength := len(xTree)
+	for i := arrayLength/2 - 1; i >= 0; i-- {
+		// put -1 as key of internal nodes where no descendant leaf contains input data.
+		if xTree[2*i+1] == -1 && xTree[2*i+2] == -1 {
+			xTree[i] = -1
+		} else {
+			key := maxLeftSubTree(i)
+			if key != -1 {
+				xTree[i] = key
+			} else {
+				xTree[i] = int(1<<31 - 1)
+			}
+		}
+	}
+
+	return xTree
+}
+
+// Builds the xTree.
+func (self *RankSpaceIndex) makeTreeOnXAxis() {
+	sort.Sort(byXRank(self.pointsRankSpace))
+	self.makeTreeOnSortedXAxis()
+}
+
+// Builds the xTree, assuming self.pointsRankSpace is already sorted by x-rank.
+// Afterwards the points of each bucket are sorted by y-rank instead.
+func (self *RankSpaceIndex) makeTreeOnSortedXAxis() {
+	arrayLength := max(2*getNextPowerOfTwo(self.numberOfBuckets())-1, 0)
+	self.xTree = make([]int, arrayLength)
+
+	self.xTree = setLeavesOfXTree(self.xTree, self.pointsRankSpace, self.bucketSize)
+
+	self.xTree = setInternalNodesOfXTree(self.xTree)
+
+	self.setXTreeHeight()
+	self.firstLeaf = len(self.xTree) / 2
+	self.xTree = self.applyLayout(self.xTree)
+	self.setChildSlots()
+
+	if self.bucketSize > 1 {
+		for start := 0; start < len(self.pointsRankSpace); start += self.bucketSize {
+			bucket := self.pointsRankSpace[start:min(start+self.bucketSize, len(self.pointsRankSpace))]
+			slices.SortFunc(bucket, func(a, b pointRankPerm) int { return cmp.Compare(a.y, b.y) })
+		}
+	}
+}
+
+// The number of leaves that hold points.
+func (self *RankSpaceIndex) numberOfBuckets() int {
+	return (len(self.pointsRankSpace) + self.bucketSize - 1) / self.bucketSize
+}
+
+// Moves the keys of xTree, which is in BFS order, to their slots in self.layout. The leaves stay where they are.
+func (self *RankSpaceIndex) applyLayout(xTree []int) []int {
+	if self.layout == LayoutBFS || len(xTree) == 0 {
+		return xTree
+	}
+	result := make([]int, len(xTree))
+	for node, key := range xTree {
+		result[self.slot(node)] = key
+	}
+	return result
+}
+
+// compute the height of xTree and set the field xTreeHeight.
+// Height is here defined as the number of nodes from root-to-leaf including both.
+func (self *RankSpaceIndex) setXTreeHeight() {
+	arrayLength := len(self.xTree)
+	height := uint(1)
+	for 1<<height < arrayLength {
+		height++
+	}
+	self.xTreeHeight = int(height)
+}
+
+// Selects how the nodes of the tree are laid out in memory, see TreeLayout. Must be called before Build.
+func (self *RankSpaceIndex) SetLayout(layout TreeLayout) {
+	self.layout = layout
+}
+
+// Lets the leaves of the tree hold up to bucketSize points each (1 by default), which are scanned linearly when queried.
+// This removes the lowest log(bucketSize) levels of the tree, and with them most of the memory used,
+// at the cost of O(bucketSize) extra work for the (at most two) leaves on the boundary of a query.
+// Values less than 1 are treated as 1. Must be called before Build.
+func (self *RankSpaceIndex) SetBucketSize(bucketSize int) {
+	self.bucketSize = max(bucketSize, 1)
+}
+
+// Sets the factory used to create the rank structures of the bit arrays, overriding the package-level RankSelectFactory.
+// Must be called before Build.
+func (self *RankSpaceIndex) SetRankSelectFactory(factory RankerFactory) {
+	self.rankSelectFactory = factory
+}
+
+// Must be called before any query can be answered.
+func (self *RankSpaceIndex) Build() {
+	if self.rankSelectFactory == nil {
+		self.rankSelectFactory = RankSelectFactory
+	}
+	self.makeTreeOnXAxis()
+	self.buildRankSelectAndBallInheritance()
+	self.buildColorTrees(1)
+	self.buildAttributeTrees(1)
+}
+
+// Same as Build, but uses up to workers goroutines.
+// If workers is less than 1, runtime.GOMAXPROCS(0) goroutines are used.
+// The resulting structure answers queries exactly like one produced by Build.
+func (self *RankSpaceIndex) BuildParallel(workers int) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if self.rankSelectFactory == nil {
+		self.rankSelectFactory = RankSelectFactory
+	}
+	parallelSort(self.pointsRankSpace, func(a, b pointRankPerm) int { return cmp.Compare(a.x, b.x) }, workers)
+	self.makeTreeOnSortedXAxis()
+	self.buildRankSelectAndBallInheritanceParallel(workers)
+	self.buildColorTrees(workers)
+	self.buildAttributeTrees(workers)
+}
+
+// Constructor: takes the points in rank space, see RankSpaceIndex. Indices reported by queries refer to this slice.
+func NewRankSpaceIndex(points []RankPoint) *RankSpaceIndex {
+	result := new(RankSpaceIndex)
+	result.points = points
+	result.bucketSize = 1
+	result.pointsRankSpace = make([]pointRankPerm, len(points))
+	for i, p := range points {
+		result.pointsRankSpace[i] = pointRankPerm{p.X, p.Y, i}
+	}
+	return result
+}
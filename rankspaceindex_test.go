@@ -0,0 +1,129 @@
+package goors
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestLowestCommonAncestor(t *testing.T) {
+	lefts := []int{1, 3, 4, 8, 9, 19}
+	rights := []int{2, 4, 6, 9, 11, 22}
+	answers := []int{0, 1, 0, 1, 0, 4}
+
+	for i := 0; i < len(lefts); i++ {
+		lca := lowestCommonAncestor(lefts[i], rights[i])
+		if answers[i] != lca {
+			fmt.Println("error: LCA(", lefts[i], ",", rights[i], ") returned", lca, " but expected", answers[i])
+			t.Fail()
+		}
+	}
+}
+
+func TestMakeTreeOnXAxis(t *testing.T) {
+	n := 6
+	points := make([]pointRankPerm, n)
+	for i := 0; i < n; i++ {
+		points[i].x = i
+	}
+
+	ds := NewRankSpaceIndex([]RankPoint{})
+	ds.pointsRankSpace = points
+	ds.makeTreeOnXAxis()
+	xTree := ds.xTree
+	height := ds.xTreeHeight
+	if height != 4 {
+		fmt.Println("Expected height 4 but received height", height)
+		t.Fail()
+	}
+	noData := -1
+	correctArray := []int{3, 1, 5, 0, 2, 4, noData, 0, 1, 2, 3, 4, 5, noData, noData}
+	correct := true
+	for i, v := range correctArray {
+		if xTree[i] != v {
+			t.Fail()
+			fmt.Println("xTree is not correct")
+			correct = false
+		}
+	}
+	if !correct {
+		fmt.Println("xTree:")
+		fmt.Println(xTree)
+		fmt.Println("correct:")
+		fmt.Println(correctArray)
+	}
+}
+
+func TestQueryRanks(t *testing.T) {
+	n := 1000
+	rand.Seed(19)
+	xs := rand.Perm(n)
+	ys := rand.Perm(n)
+	points := make([]RankPoint, n)
+	for i := range points {
+		points[i] = RankPoint{xs[i], ys[i]}
+	}
+	ds := NewRankSpaceIndex(points)
+	ds.Build()
+
+	for i := 0; i < 500; i++ {
+		x0, x1 := rand.Intn(n+2)-1, rand.Intn(n+2)-1
+		y0, y1 := rand.Intn(n+2)-1, rand.Intn(n+2)-1
+		expected := []int{}
+		for index, p := range points {
+			if p.X >= x0 && p.X < x1 && p.Y >= y0 && p.Y < y1 {
+				expected = append(expected, index)
+			}
+		}
+		result := ds.QueryRanks(x0, x1, y0, y1)
+		if !sameIndices(expected, result) {
+			fmt.Println("QueryRanks(", x0, x1, y0, y1, ") returned", len(result), "points, expected", len(expected))
+			t.Fail()
+		}
+	}
+}
+
+func TestBucketSizes(t *testing.T) {
+	n := 1000
+	rand.Seed(23)
+	xs := rand.Perm(n)
+	ys := rand.Perm(n)
+	points := make([]RankPoint, n)
+	for i := range points {
+		points[i] = RankPoint{xs[i], ys[i]}
+	}
+
+	for _, bucketSize := range []int{0, 1, 2, 3, 16, 100, n, 2 * n} {
+		for _, layout := range allLayouts {
+			ds := NewRankSpaceIndex(points)
+			ds.SetBucketSize(bucketSize)
+			ds.SetLayout(layout)
+			ds.Build()
+
+			for i := 0; i < 200; i++ {
+				x0, x1 := rand.Intn(n+2)-1, rand.Intn(n+2)-1
+				y0, y1 := rand.Intn(n+2)-1, rand.Intn(n+2)-1
+				expected := []int{}
+				for index, p := range points {
+					if p.X >= x0 && p.X < x1 && p.Y >= y0 && p.Y < y1 {
+						expected = append(expected, index)
+					}
+				}
+				result := ds.QueryRanks(x0, x1, y0, y1)
+				if !sameIndices(expected, result) {
+					fmt.Println("bucket size", bucketSize, layout, "QueryRanks(", x0, x1, y0, y1, ") returned", len(result), "points, expected", len(expected))
+					t.Fail()
+				}
+			}
+		}
+	}
+
+	// a bucket size of 16 removes the lowest 4 levels of the tree.
+	ds := NewRankSpaceIndex(points)
+	ds.SetBucketSize(16)
+	ds.Build()
+	if ds.xTreeHeight != 7 || len(ds.rankSelectStructures) != 63 {
+		fmt.Println("expected height 7 and 63 rank-select structures, got", ds.xTreeHeight, len(ds.rankSelectStructures))
+		t.Fail()
+	}
+}
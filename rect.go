@@ -0,0 +1,16 @@
+package goors
+
+// A RectOf is the closed axis-parallel rectangle spanned by its bottom-left and top-right corners.
+type RectOf[T any] struct {
+	bottomLeft, topRight PointOf[T]
+}
+
+func MakeRectOf[T any](bottomLeft, topRight PointOf[T]) RectOf[T] {
+	return RectOf[T]{bottomLeft, topRight}
+}
+
+type Rect = RectOf[float64]
+
+func MakeRect(bottomLeft, topRight Point) Rect {
+	return Rect{bottomLeft, topRight}
+}
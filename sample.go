@@ -0,0 +1,73 @@
+package goors
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Returns m of the points Query would report, drawn uniformly at random without replacement using rng.
+// If there are at most m points in the rectangle, all of them are returned.
+// The rectangle is not enumerated: a point is drawn by choosing a position in it, finding the piece of the query
+// holding that position (every piece knows its size) and looking it up in that piece's ball-inheritance.
+func (self *RangeSearchAdvancedOf[T]) Sample(bottomLeft, topRight PointOf[T], m int, rng *rand.Rand) []int {
+	if self.index == nil {
+		// not built yet.
+		return []int{}
+	}
+	x0, x1, y0, y1 := self.rankSpace.ReduceRect(MakeRectOf(bottomLeft, topRight))
+	return self.index.SampleRanks(x0, x1, y0, y1, m, rng)
+}
+
+// Same as Sample, for a query in rank space (see QueryRanks).
+func (self *RankSpaceIndex) SampleRanks(x0, x1, y0, y1, m int, rng *rand.Rand) []int {
+	d := self.decompose(x0, x1, y0, y1, self.descendToLca)
+	count := self.count(d)
+	if count <= m {
+		return self.report(d, count)
+	}
+	if m <= 0 {
+		return []int{}
+	}
+
+	// starts[i] is the position of the first point of piece i among all the points of the query.
+	// The partial pieces are reported up front, as their points are not a contiguous range of a bucket.
+	starts := make([]int, len(d.pieces))
+	partials := make(map[int][]int)
+	position := 0
+	for i, p := range d.pieces {
+		starts[i] = position
+		if p.partial {
+			partials[i] = self.reportPiece(nil, d, p)
+		}
+		position += self.pieceSize(d, p)
+	}
+
+	// Floyd's algorithm for drawing m distinct positions out of count.
+	// The positions are kept in the order they are drawn, so the result only depends on rng.
+	chosen := make(map[int]bool, m)
+	positions := make([]int, 0, m)
+	for j := count - m; j < count; j++ {
+		t := rng.Intn(j + 1)
+		if chosen[t] {
+			t = j
+		}
+		chosen[t] = true
+		positions = append(positions, t)
+	}
+
+	result := make([]int, 0, m)
+	for _, position := range positions {
+		i := sort.Search(len(starts), func(i int) bool { return starts[i] > position }) - 1
+		offset := position - starts[i]
+		p := d.pieces[i]
+		switch {
+		case p.partial:
+			result = append(result, partials[i][offset])
+		case isLeaf(p.node, self):
+			result = append(result, self.bucket(p.node)[p.yLeft+offset].i)
+		default:
+			result = append(result, self.ballInheritance[p.slot][p.yLeft+offset])
+		}
+	}
+	return result
+}
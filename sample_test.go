@@ -0,0 +1,64 @@
+package goors
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	size := 5000
+	points := make([]Point, size)
+	rand.Seed(47)
+	for i := 0; i < size; i++ {
+		points[i] = Point{rand.Float64(), rand.Float64()}
+	}
+	ds := NewRangeSearchAdvanced(points)
+	ds.SetBucketSize(4)
+	ds.Build()
+	rng := rand.New(rand.NewSource(47))
+
+	for i := 0; i < 200; i++ {
+		x1, x2, y1, y2 := rand.Float64(), rand.Float64(), rand.Float64(), rand.Float64()
+		bottomLeft := Point{math.Min(x1, x2), math.Min(y1, y2)}
+		topRight := Point{math.Max(x1, x2), math.Max(y1, y2)}
+		expected := ds.Query(bottomLeft, topRight)
+		inside := make(map[int]bool)
+		for _, v := range expected {
+			inside[v] = true
+		}
+		m := rand.Intn(len(expected) + 10)
+		result := ds.Sample(bottomLeft, topRight, m, rng)
+		if len(result) != min(m, len(expected)) {
+			fmt.Println("Sample of", m, "out of", len(expected), "returned", len(result), "points")
+			t.Fail()
+		}
+		seen := make(map[int]bool)
+		for _, v := range result {
+			if seen[v] || !inside[v] {
+				fmt.Println("Sample returned", v, "which is a duplicate or outside the rectangle")
+				t.Fail()
+			}
+			seen[v] = true
+		}
+	}
+
+	// every point in the rectangle should be drawn about equally often.
+	bottomLeft, topRight := Point{0.2, 0.3}, Point{0.3, 0.4}
+	expected := ds.Query(bottomLeft, topRight)
+	counts := make(map[int]int)
+	trials, m := 20000, 5
+	for i := 0; i < trials; i++ {
+		for _, v := range ds.Sample(bottomLeft, topRight, m, rng) {
+			counts[v]++
+		}
+	}
+	mean := float64(trials*m) / float64(len(expected))
+	for _, v := range expected {
+		if math.Abs(float64(counts[v])-mean) > 0.2*mean {
+			fmt.Println("point", v, "was drawn", counts[v], "times, expected about", mean)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package goors
+
+import "sort"
+
+// Returns the point in the rectangle with the k'th smallest coordinate on the given axis (counting from 0),
+// points with the same coordinate being ordered by index. Returns -1 if there are at most k points in the rectangle.
+// This takes O(log² n) time, regardless of the number of points in the rectangle.
+func (self *RangeSearchAdvancedOf[T]) SelectKth(bottomLeft, topRight PointOf[T], k int, by Axis) int {
+	if self.index == nil {
+		// not built yet.
+		return -1
+	}
+	x0, x1, y0, y1 := self.rankSpace.ReduceRect(MakeRectOf(bottomLeft, topRight))
+	return self.index.SelectKthRanks(x0, x1, y0, y1, k, by)
+}
+
+// Returns the point in the rectangle with the median coordinate on the given axis, or -1 if the rectangle is empty.
+// For an even number of points it is the lower of the two middle ones.
+func (self *RangeSearchAdvancedOf[T]) Median(bottomLeft, topRight PointOf[T], by Axis) int {
+	if self.index == nil {
+		// not built yet.
+		return -1
+	}
+	x0, x1, y0, y1 := self.rankSpace.ReduceRect(MakeRectOf(bottomLeft, topRight))
+	return self.index.SelectKthRanks(x0, x1, y0, y1, (self.index.CountRanks(x0, x1, y0, y1)-1)/2, by)
+}
+
+// Same as SelectKth, for a query in rank space (see QueryRanks).
+// The rank of the k'th point is the smallest r such that more than k points of the query have a rank of at most r,
+// which is found by binary search using CountRanks. Since ranks are distinct, only one point has rank r.
+func (self *RankSpaceIndex) SelectKthRanks(x0, x1, y0, y1, k int, by Axis) int {
+	x0, y0 = max(x0, 0), max(y0, 0)
+	x1, y1 = min(x1, len(self.points)), min(y1, len(self.points))
+	if k < 0 || k >= self.CountRanks(x0, x1, y0, y1) {
+		return -1
+	}
+	if by == AxisX {
+		r := x0 + sort.Search(x1-x0, func(i int) bool { return self.CountRanks(x0, x0+i+1, y0, y1) > k })
+		return self.QueryRanks(r, r+1, y0, y1)[0]
+	}
+	r := y0 + sort.Search(y1-y0, func(i int) bool { return self.CountRanks(x0, x1, y0, y0+i+1) > k })
+	return self.QueryRanks(x0, x1, r, r+1)[0]
+}
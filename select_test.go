@@ -0,0 +1,61 @@
+package goors
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestSelectKth(t *testing.T) {
+	size := 2000
+	points := make([]Point, size)
+	rand.Seed(53)
+	for i := 0; i < size; i++ {
+		points[i] = Point{float64(rand.Intn(60)), float64(rand.Intn(60))}
+	}
+	ds := NewRangeSearchAdvanced(points)
+	ds.Build()
+
+	for i := 0; i < 200; i++ {
+		x1, x2 := float64(rand.Intn(64)-2), float64(rand.Intn(64)-2)
+		y1, y2 := float64(rand.Intn(64)-2), float64(rand.Intn(64)-2)
+		bottomLeft := Point{math.Min(x1, x2), math.Min(y1, y2)}
+		topRight := Point{math.Max(x1, x2), math.Max(y1, y2)}
+		for _, by := range []Axis{AxisX, AxisY} {
+			expected := ds.Query(bottomLeft, topRight)
+			coordinate := func(i int) float64 {
+				if by == AxisX {
+					return points[i].x
+				}
+				return points[i].y
+			}
+			slices.SortFunc(expected, func(a, b int) int {
+				if c := cmp.Compare(coordinate(a), coordinate(b)); c != 0 {
+					return c
+				}
+				return a - b
+			})
+			for _, k := range []int{-1, 0, len(expected) / 3, len(expected) - 1, len(expected)} {
+				want := -1
+				if k >= 0 && k < len(expected) {
+					want = expected[k]
+				}
+				if got := ds.SelectKth(bottomLeft, topRight, k, by); got != want {
+					fmt.Println("SelectKth(", bottomLeft, topRight, k, by, ") =", got, "expected", want)
+					t.Fail()
+				}
+			}
+			want := -1
+			if len(expected) > 0 {
+				want = expected[(len(expected)-1)/2]
+			}
+			if got := ds.Median(bottomLeft, topRight, by); got != want {
+				fmt.Println("Median(", bottomLeft, topRight, by, ") =", got, "expected", want)
+				t.Fail()
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package goors
+
+import "math"
+
+// Reports the points at distance at most r from center.
+// The points in the bounding box of the circle are found as pieces (see the README); a piece whose bounding box
+// lies inside the circle is reported as a whole, and only the points of the other pieces are tested one by one.
+func (self *RangeSearchAdvanced) QueryCircle(center Point, r float64) []int {
+	if !(r >= 0) {
+		return []int{}
+	}
+	inside := func(p Point) bool {
+		dx, dy := p.x-center.x, p.y-center.y
+		return dx*dx+dy*dy <= r*r
+	}
+	insideRect := func(bottomLeft, topRight Point) bool {
+		return inside(bottomLeft) && inside(topRight) &&
+			inside(Point{bottomLeft.x, topRight.y}) && inside(Point{topRight.x, bottomLeft.y})
+	}
+	return self.queryShape(Point{center.x - r, center.y - r}, Point{center.x + r, center.y + r}, inside, insideRect)
+}
+
+// Reports the points inside the polygon with the given vertices, in order (either direction). The polygon may be
+// non-convex, but its edges should not cross. Whether a point on an edge is reported is not specified.
+// Pieces are accepted as a whole when their bounding box is inside the polygon, as with QueryCircle.
+func (self *RangeSearchAdvanced) QueryPolygon(polygon []Point) []int {
+	if len(polygon) < 3 {
+		return []int{}
+	}
+	bottomLeft, topRight := polygon[0], polygon[0]
+	for _, p := range polygon {
+		bottomLeft = Point{math.Min(bottomLeft.x, p.x), math.Min(bottomLeft.y, p.y)}
+		topRight = Point{math.Max(topRight.x, p.x), math.Max(topRight.y, p.y)}
+	}
+	inside := func(p Point) bool {
+		return insidePolygon(polygon, p)
+	}
+	// if no edge touches the rectangle it is either entirely inside or entirely outside.
+	insideRect := func(bottomLeft, topRight Point) bool {
+		for i := range polygon {
+			if segmentTouchesRect(polygon[i], polygon[(i+1)%len(polygon)], bottomLeft, topRight) {
+				return false
+			}
+		}
+		return inside(bottomLeft)
+	}
+	return self.queryShape(bottomLeft, topRight, inside, insideRect)
+}
+
+// Reports the points in the rectangle for which inside is true. insideRect(bottomLeft, topRight) must only be true
+// if inside is true for every point of that rectangle.
+func (self *RangeSearchAdvanced) queryShape(bottomLeft, topRight Point, inside func(Point) bool, insideRect func(Point, Point) bool) []int {
+	if self.index == nil {
+		// not built yet.
+		return []int{}
+	}
+	x0, x1, y0, y1 := self.rankSpace.ReduceRect(MakeRect(bottomLeft, topRight))
+	d := self.index.decompose(x0, x1, y0, y1, self.index.descendToLca)
+	result := []int{}
+	for _, p := range d.pieces {
+		if !p.partial {
+			bx0, bx1, by0, by1 := self.index.pieceBox(p)
+			boxBottomLeft := Point{self.rankSpace.CoordOf(AxisX, bx0), self.rankSpace.CoordOf(AxisY, by0)}
+			boxTopRight := Point{self.rankSpace.CoordOf(AxisX, bx1-1), self.rankSpace.CoordOf(AxisY, by1-1)}
+			if insideRect(boxBottomLeft, boxTopRight) {
+				result = self.index.reportPiece(result, d, p)
+				continue
+			}
+		}
+		for _, i := range self.index.reportPiece(nil, d, p) {
+			if inside(self.points[i]) {
+				result = append(result, i)
+			}
+		}
+	}
+	return result
+}
+
+// Even-odd rule: p is inside if a ray from p to the right crosses the edges an odd number of times.
+func insidePolygon(polygon []Point, p Point) bool {
+	inside := false
+	for i := range polygon {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		if (a.y > p.y) != (b.y > p.y) && p.x < a.x+(p.y-a.y)*(b.x-a.x)/(b.y-a.y) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Checks if the segment from a to b has a point in the closed rectangle, by clipping it (Liang-Barsky).
+func segmentTouchesRect(a, b, bottomLeft, topRight Point) bool {
+	t0, t1 := 0.0, 1.0
+	dx, dy := b.x-a.x, b.y-a.y
+	clip := func(p, q float64) bool {
+		// the part of the segment with p*t <= q is kept.
+		if p == 0 {
+			return q >= 0
+		}
+		t := q / p
+		if p < 0 {
+			t0 = math.Max(t0, t)
+		} else {
+			t1 = math.Min(t1, t)
+		}
+		return t0 <= t1
+	}
+	return clip(-dx, a.x-bottomLeft.x) && clip(dx, topRight.x-a.x) &&
+		clip(-dy, a.y-bottomLeft.y) && clip(dy, topRight.y-a.y)
+}
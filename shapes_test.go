@@ -0,0 +1,78 @@
+package goors
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestQueryCircle(t *testing.T) {
+	size := 5000
+	points := make([]Point, size)
+	rand.Seed(71)
+	for i := 0; i < size; i++ {
+		points[i] = Point{rand.Float64(), rand.Float64()}
+	}
+	ds := NewRangeSearchAdvanced(points)
+	ds.Build()
+
+	for i := 0; i < 200; i++ {
+		center := Point{rand.Float64(), rand.Float64()}
+		r := rand.Float64() / 2
+		expected := []int{}
+		for j, p := range points {
+			if math.Hypot(p.x-center.x, p.y-center.y) <= r {
+				expected = append(expected, j)
+			}
+		}
+		if result := ds.QueryCircle(center, r); !sameIndices(expected, result) {
+			fmt.Println("QueryCircle(", center, r, ") returned", len(result), "points, expected", len(expected))
+			t.Fail()
+		}
+	}
+}
+
+func TestQueryPolygon(t *testing.T) {
+	size := 5000
+	points := make([]Point, size)
+	rand.Seed(73)
+	for i := 0; i < size; i++ {
+		points[i] = Point{rand.Float64(), rand.Float64()}
+	}
+	ds := NewRangeSearchAdvanced(points)
+	ds.SetBucketSize(4)
+	ds.Build()
+
+	for i := 0; i < 200; i++ {
+		// a star shaped (so in general non-convex) polygon around a random center.
+		center := Point{rand.Float64(), rand.Float64()}
+		vertices := 3 + rand.Intn(10)
+		polygon := make([]Point, vertices)
+		for j := range polygon {
+			angle := 2 * math.Pi * float64(j) / float64(vertices)
+			radius := 0.05 + rand.Float64()/2
+			polygon[j] = Point{center.x + radius*math.Cos(angle), center.y + radius*math.Sin(angle)}
+		}
+		expected := []int{}
+		for j, p := range points {
+			if insidePolygon(polygon, p) {
+				expected = append(expected, j)
+			}
+		}
+		if result := ds.QueryPolygon(polygon); !sameIndices(expected, result) {
+			fmt.Println("QueryPolygon(", polygon, ") returned", len(result), "points, expected", len(expected))
+			t.Fail()
+		}
+	}
+
+	// a square with a square hole cut into it from the top.
+	polygon := []Point{{0.1, 0.1}, {0.9, 0.1}, {0.9, 0.9}, {0.6, 0.9}, {0.6, 0.4}, {0.4, 0.4}, {0.4, 0.9}, {0.1, 0.9}}
+	for _, i := range ds.QueryPolygon(polygon) {
+		p := points[i]
+		if p.x < 0.1 || p.x > 0.9 || p.y < 0.1 || p.y > 0.9 || p.x > 0.4 && p.x < 0.6 && p.y > 0.4 {
+			fmt.Println("QueryPolygon reported", p, "which is outside the polygon")
+			t.Fail()
+		}
+	}
+}
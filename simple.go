@@ -1,29 +1,60 @@
 package goors
 
+import "cmp"
+
+// RangeSearchSimpleOf answers queries by scanning all the points. It is mostly useful as a reference for testing.
+// It is never modified by Query, so it can be queried from any number of goroutines concurrently.
+type RangeSearchSimpleOf[T any] struct {
+	points  []PointOf[T]
+	compare func(a, b T) int
+}
+
+// RangeSearchSimple is the RangeSearchSimpleOf used for float64 coordinates.
 type RangeSearchSimple struct {
-	points []Point
+	RangeSearchSimpleOf[float64]
 }
 
-func (self *RangeSearchSimple) Query(bottomLeft, topRight Point) []int {
+func (self *RangeSearchSimpleOf[T]) Query(bottomLeft, topRight PointOf[T]) []int {
 	var result = []int{}
-	isContained := func(point Point) bool {
-		return point.x >= bottomLeft.x && point.x <= topRight.x &&
-			point.y >= bottomLeft.y && point.y <= topRight.y
-	}
 	for index, point := range self.points {
-		if isContained(point) {
+		if isContained(self.compare, bottomLeft, topRight, point) {
 			result = append(result, index)
 		}
 	}
 	return result
 }
 
-func (self *RangeSearchSimple) Build() {
+func (self *RangeSearchSimpleOf[T]) QueryLimit(bottomLeft, topRight PointOf[T], limit int) ([]int, bool) {
+	var result = []int{}
+	for index, point := range self.points {
+		if isContained(self.compare, bottomLeft, topRight, point) {
+			if len(result) >= limit {
+				return result, true
+			}
+			result = append(result, index)
+		}
+	}
+	return result, false
+}
+
+func (self *RangeSearchSimpleOf[T]) Build() {
 
 }
 
 func NewRangeSearchSimple(points []Point) *RangeSearchSimple {
-	result := new(RangeSearchSimple)
+	return &RangeSearchSimple{*NewRangeSearchSimpleOf(points)}
+}
+
+// Constructor for coordinates with a natural ordering, such as integers, floats and strings.
+func NewRangeSearchSimpleOf[T cmp.Ordered](points []PointOf[T]) *RangeSearchSimpleOf[T] {
+	return NewRangeSearchSimpleFunc(points, cmp.Compare[T])
+}
+
+// Constructor for coordinates ordered by compare, which must return a negative number, zero or a positive number
+// when a is less than, equal to or greater than b (e.g. time.Time.Compare).
+func NewRangeSearchSimpleFunc[T any](points []PointOf[T], compare func(a, b T) int) *RangeSearchSimpleOf[T] {
+	result := new(RangeSearchSimpleOf[T])
 	result.points = points
+	result.compare = compare
 	return result
 }
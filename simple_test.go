@@ -1,8 +1,11 @@
 package goors
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -35,3 +38,29 @@ func BenchmarkSimple(b *testing.B) {
 
 	result_simple_test = sum
 }
+
+// Run with -race to detect data races between concurrent readers.
+func TestSimpleConcurrentQueries(t *testing.T) {
+	points := []Point{{0.0, 0.0}, {5.0, 5.0}, {10.0, 10.0}, {15.0, 15.0}}
+	ds := NewRangeSearchSimple(points)
+	ds.Build()
+
+	var wg sync.WaitGroup
+	var failures int32
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				if !sameIndices([]int{1, 2}, ds.Query(Point{4.0, 4.0}, Point{11.0, 11.0})) {
+					atomic.AddInt32(&failures, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if failures != 0 {
+		fmt.Println(failures, "concurrent queries gave a wrong result")
+		t.Fail()
+	}
+}